@@ -0,0 +1,247 @@
+package webchat
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PurgeHistoryFn removes stored history for room, or all rooms when room is
+// "*". It returns the number of messages removed.
+type PurgeHistoryFn func(room string) (int, error)
+
+// RevisionsFn returns the previous versions of an edited or deleted message
+type RevisionsFn func(room string, id int64) ([]*Message, error)
+
+// Admin serves the admin api. It should listen on a separate, private address
+// from the chat handler. Every request must carry the token as a bearer
+// token.
+type Admin struct {
+	handler *Handler
+	hub     *Hub
+	token   string
+	mux     *http.ServeMux
+
+	// PurgeHistory and Revisions are provided by the application, which owns
+	// the history
+	PurgeHistory PurgeHistoryFn
+	Revisions    RevisionsFn
+}
+
+// RoomInfo describes a room for administration
+type RoomInfo struct {
+	Room    string            `json:"room"`
+	Topic   string            `json:"topic,omitempty"`
+	Members []*ConnectionInfo `json:"members"`
+}
+
+func NewAdmin(handler *Handler, token string) (*Admin, error) {
+	if token == "" {
+		return nil, fmt.Errorf("admin token is required")
+	}
+	a := &Admin{
+		handler: handler,
+		hub:     handler.hub,
+		token:   token,
+		mux:     http.NewServeMux(),
+	}
+	a.mux.HandleFunc("/admin/connections", a.serveConnections)
+	a.mux.HandleFunc("/admin/connections/", a.serveKick)
+	a.mux.HandleFunc("/admin/bans", a.serveBans)
+	a.mux.HandleFunc("/admin/notice", a.serveNotice)
+	a.mux.HandleFunc("/admin/rooms", a.serveRooms)
+	a.mux.HandleFunc("/admin/history/purge", a.servePurge)
+	a.mux.HandleFunc("/admin/history/revisions", a.serveRevisions)
+	a.mux.HandleFunc("/admin/stats", a.serveStats)
+	return a, nil
+}
+
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	token := strings.TrimPrefix(auth, "Bearer ")
+	if token == auth || subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+		http.Error(w, "Unauthorized", 401)
+		return
+	}
+	log.Printf("admin %s %s from %s", r.Method, r.URL, r.RemoteAddr)
+	a.mux.ServeHTTP(w, r)
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func readJson(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v)
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), 400)
+		return false
+	}
+	return true
+}
+
+// GET /admin/connections
+func (a *Admin) serveConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	writeJson(w, a.hub.Connections())
+}
+
+// POST /admin/connections/{id}/kick
+func (a *Admin) serveKick(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/admin/connections/")
+	if r.Method != "POST" || strings.HasSuffix(path, "/kick") == false {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	id, err := strconv.ParseInt(strings.TrimSuffix(path, "/kick"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad connection id", 400)
+		return
+	}
+	err = a.hub.Kick(id)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	writeJson(w, map[string]int64{"kicked": id})
+}
+
+// GET lists bans, POST adds a ban and DELETE removes one
+func (a *Admin) serveBans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		writeJson(w, a.hub.Bans())
+	case "POST":
+		b := Ban{}
+		if readJson(w, r, &b) == false {
+			return
+		}
+		kicked, err := a.hub.AddBan(b)
+		if err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		writeJson(w, map[string]int{"kicked": kicked})
+	case "DELETE":
+		b := Ban{Kind: r.URL.Query().Get("kind"), Value: r.URL.Query().Get("value")}
+		if err := b.Validate(); err != nil {
+			http.Error(w, err.Error(), 400)
+			return
+		}
+		a.hub.RemoveBan(b)
+		w.WriteHeader(204)
+	default:
+		http.Error(w, "Method not allowed", 405)
+	}
+}
+
+// POST /admin/notice {"room": "", "message": "..."}
+func (a *Admin) serveNotice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	m := &Message{}
+	if readJson(w, r, m) == false {
+		return
+	}
+	if m.Message == "" {
+		http.Error(w, "empty message", 400)
+		return
+	}
+	a.hub.SendBroadcast(&Message{Op: NoticeOp, Room: m.Room, Message: m.Message})
+	w.WriteHeader(204)
+}
+
+// GET /admin/rooms
+func (a *Admin) serveRooms(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	connections := a.hub.Connections()
+	ret := make([]*RoomInfo, 0)
+	for _, room := range a.hub.Rooms() {
+		info := &RoomInfo{
+			Room:    room,
+			Topic:   a.hub.Topic(room),
+			Members: make([]*ConnectionInfo, 0),
+		}
+		for _, c := range connections {
+			for _, joined := range c.Rooms {
+				if joined == room {
+					info.Members = append(info.Members, c)
+				}
+			}
+		}
+		ret = append(ret, info)
+	}
+	writeJson(w, ret)
+}
+
+// POST /admin/history/purge?room=name, all rooms when room is *. The room
+// is required, an empty one is the default room.
+func (a *Admin) servePurge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if a.PurgeHistory == nil {
+		http.Error(w, "history is not supported", 501)
+		return
+	}
+	room, ok := r.URL.Query()["room"]
+	if ok == false {
+		http.Error(w, "missing room, * for all rooms", 400)
+		return
+	}
+	n, err := a.PurgeHistory(room[0])
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeJson(w, map[string]int{"purged": n})
+}
+
+// GET /admin/history/revisions?room=name&id=message_id
+func (a *Admin) serveRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if a.Revisions == nil {
+		http.Error(w, "history is not supported", 501)
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Bad message id", 400)
+		return
+	}
+	revisions, err := a.Revisions(r.URL.Query().Get("room"), id)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	writeJson(w, revisions)
+}
+
+// GET /admin/stats
+func (a *Admin) serveStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	writeJson(w, map[string]interface{}{
+		"hub":     a.hub.Stats(),
+		"handler": a.handler.Stats(),
+	})
+}
@@ -0,0 +1,429 @@
+package webchat
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// default and maximum page size of the messages endpoint
+	defaultMessageLimit = 50
+	maxMessageLimit     = 500
+
+	// name of DefaultRoom in api paths
+	apiDefaultRoom = "default"
+)
+
+// API serves the json rest api for rooms, history and users. Requests are
+// subject to the same origin checks as the websocket and client certificates
+// set the sender identity.
+type API struct {
+	handler *Handler
+	hub     *Hub
+	history History
+	routes  []*apiRoute
+}
+
+// RoomSummary is returned by the room listing
+type RoomSummary struct {
+	Room    string `json:"room"`
+	Topic   string `json:"topic,omitempty"`
+	Members int    `json:"members"`
+}
+
+// OnlineUser is returned by the online user listing
+type OnlineUser struct {
+	Id    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Rooms []string `json:"rooms"`
+}
+
+// ReadMarker is the body of a read marker update
+type ReadMarker struct {
+	Room      string `json:"room"`
+	MessageId int64  `json:"message_id"`
+}
+
+// PostMessage is the body of a message posted to a room
+type PostMessage struct {
+	Message string `json:"message"`
+
+	// ReplyTo optionally replies in the thread of this MessageId
+	ReplyTo int64 `json:"reply_to,omitempty"`
+
+	// Attachments uploaded by the client certificate identity
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// Data and Meta of the message, see Message
+	Data json.RawMessage   `json:"data,omitempty"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+func NewAPI(handler *Handler, history History) *API {
+	a := &API{
+		handler: handler,
+		hub:     handler.hub,
+		history: history,
+	}
+	a.routes = []*apiRoute{
+		{
+			Method:   "GET",
+			Pattern:  "/api/rooms",
+			Summary:  "List rooms with members or a topic",
+			Response: []RoomSummary{},
+			handler:  a.listRooms,
+		},
+		{
+			Method:  "GET",
+			Pattern: "/api/rooms/{room}/messages",
+			Summary: "Page through room history, newest page first",
+			Params: []apiParam{
+				{Name: "room", In: "path", Type: "string", Doc: "room name, default for the default room"},
+				{Name: "before", In: "query", Type: "integer", Doc: "only messages with a lower message_id"},
+				{Name: "limit", In: "query", Type: "integer", Doc: "page size, at most 500"},
+			},
+			Response: []Message{},
+			handler:  a.listMessages,
+		},
+		{
+			Method:  "POST",
+			Pattern: "/api/rooms/{room}/messages",
+			Summary: "Send a message to a room as the client certificate identity",
+			Params: []apiParam{
+				{Name: "room", In: "path", Type: "string", Doc: "room name, default for the default room"},
+			},
+			Request: PostMessage{},
+			Status:  202,
+			handler: a.postMessage,
+		},
+		{
+			Method:  "GET",
+			Pattern: "/api/rooms/{room}/threads/{id}",
+			Summary: "Get a thread root and its replies, oldest first",
+			Params: []apiParam{
+				{Name: "room", In: "path", Type: "string", Doc: "room name, default for the default room"},
+				{Name: "id", In: "path", Type: "integer", Doc: "message_id of the thread root"},
+			},
+			Response: []Message{},
+			handler:  a.getThread,
+		},
+		{
+			Method:  "GET",
+			Pattern: "/api/search",
+			Summary: "Search the history of the rooms the client certificate identity is in, newest first",
+			Params: []apiParam{
+				{Name: "q", In: "query", Type: "string", Doc: "words, from:nick, in:room, after:date and before:date"},
+				{Name: "from", In: "query", Type: "string", Doc: "only messages from this nick"},
+				{Name: "room", In: "query", Type: "string", Doc: "only messages in this room, may be repeated"},
+				{Name: "since", In: "query", Type: "string", Doc: "only messages at or after this date"},
+				{Name: "until", In: "query", Type: "string", Doc: "only messages before this date"},
+				{Name: "limit", In: "query", Type: "integer", Doc: "number of results, at most 200"},
+			},
+			Response: []Message{},
+			handler:  a.search,
+		},
+		{
+			Method:   "GET",
+			Pattern:  "/api/users/online",
+			Summary:  "List connected users",
+			Response: []OnlineUser{},
+			handler:  a.listOnline,
+		},
+		{
+			Method:   "GET",
+			Pattern:  "/api/users/me/unread",
+			Summary:  "Unread message counts by room for the client certificate identity",
+			Response: map[string]int{},
+			handler:  a.getUnread,
+		},
+		{
+			Method:  "POST",
+			Pattern: "/api/users/me/read",
+			Summary: "Mark a room read up to a message for the client certificate identity",
+			Request: ReadMarker{},
+			Status:  204,
+			handler: a.markRead,
+		},
+		{
+			Method:      "POST",
+			Pattern:     "/api/uploads",
+			Summary:     "Upload a file to attach to a message, for the client certificate identity",
+			Request:     UploadForm{},
+			RequestType: "multipart/form-data",
+			Response:    Attachment{},
+			Status:      201,
+			handler:     a.postUpload,
+		},
+		{
+			Method:  "GET",
+			Pattern: "/api/uploads/{key}",
+			Summary: "Download an uploaded file",
+			Params: []apiParam{
+				{Name: "key", In: "path", Type: "string", Doc: "last path segment of an attachment url"},
+			},
+			handler: a.getUpload,
+		},
+		{
+			Method:  "GET",
+			Pattern: "/api/media/{key}",
+			Summary: "Get an uploaded image or thumbnail for display",
+			Params: []apiParam{
+				{Name: "key", In: "path", Type: "string", Doc: "last path segment of an attachment thumbnail url"},
+			},
+			handler: a.getMedia,
+		},
+		{
+			Method:   "GET",
+			Pattern:  "/api/protocol",
+			Summary:  "Protocol version, features and opcode numbers by name",
+			Response: Protocol{},
+			handler:  a.getProtocol,
+		},
+		{
+			Method:   "GET",
+			Pattern:  "/api/openapi.json",
+			Summary:  "This document",
+			Response: map[string]interface{}{},
+			handler:  a.serveOpenAPI,
+		},
+	}
+	return a
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.handler.checkOrigin(r) == false {
+		http.Error(w, "Forbidden", 403)
+		return
+	}
+	methodAllowed := true
+	for _, route := range a.routes {
+		vars, ok := route.match(r.URL.Path)
+		if ok == false {
+			continue
+		}
+		if route.Method != r.Method {
+			methodAllowed = false
+			continue
+		}
+		route.handler(w, r, vars)
+		return
+	}
+	if methodAllowed == false {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	http.Error(w, "Not found", 404)
+}
+
+// apiRoom maps a room name in a path to a room
+func apiRoom(name string) string {
+	if name == apiDefaultRoom {
+		return DefaultRoom
+	}
+	return name
+}
+
+func (a *API) listRooms(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	ret := make([]RoomSummary, 0)
+	for _, room := range a.hub.Rooms() {
+		ret = append(ret, RoomSummary{
+			Room:    room,
+			Topic:   a.hub.Topic(room),
+			Members: len(a.hub.Members(room)),
+		})
+	}
+	writeJson(w, ret)
+}
+
+func (a *API) listMessages(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	q := r.URL.Query()
+	var before int64
+	var err error
+	if q.Get("before") != "" {
+		before, err = strconv.ParseInt(q.Get("before"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid before", 400)
+			return
+		}
+	}
+	limit := defaultMessageLimit
+	if q.Get("limit") != "" {
+		limit, err = strconv.Atoi(q.Get("limit"))
+		if err != nil || limit < 1 {
+			http.Error(w, "invalid limit", 400)
+			return
+		}
+		if limit > maxMessageLimit {
+			limit = maxMessageLimit
+		}
+	}
+	messages, err := a.history.Messages(apiRoom(vars["room"]), before, limit)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeJson(w, messages)
+}
+
+func (a *API) postMessage(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	// the sender is the identity, anonymous callers could pose as anyone
+	identity, ok := a.identity(w, r)
+	if ok == false {
+		return
+	}
+	if a.hub.bannedAs(r.RemoteAddr, identity, identity) {
+		http.Error(w, "banned", 403)
+		return
+	}
+	body := &PostMessage{}
+	if readJson(w, r, body) == false {
+		return
+	}
+	if strings.TrimSpace(body.Message) == "" && len(body.Attachments) == 0 {
+		http.Error(w, "a message or attachments are required", 400)
+		return
+	}
+	if len(body.Message) > maxMessageSize {
+		http.Error(w, "message too large", 413)
+		return
+	}
+	m := &Message{
+		Op:          MessageOp,
+		Room:        apiRoom(vars["room"]),
+		From:        identity,
+		Message:     body.Message,
+		ReplyTo:     body.ReplyTo,
+		Attachments: body.Attachments,
+		Data:        body.Data,
+		Meta:        body.Meta,
+		Author:      IdentityAuthor(identity),
+	}
+	if err := m.checkNames(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if err := m.validate(); err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	a.hub.Post(m)
+	w.WriteHeader(202)
+}
+
+func (a *API) getThread(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", 400)
+		return
+	}
+	messages, err := a.history.Thread(apiRoom(vars["room"]), id)
+	if err != nil {
+		http.Error(w, err.Error(), 404)
+		return
+	}
+	writeJson(w, messages)
+}
+
+func (a *API) search(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if a.hub.index == nil {
+		http.Error(w, "search is disabled", 501)
+		return
+	}
+	identity, ok := a.identity(w, r)
+	if ok == false {
+		return
+	}
+	v := r.URL.Query()
+	q, err := ParseSearchQuery(v.Get("q"))
+	if err == nil && v.Get("since") != "" {
+		q.Since, err = parseSearchTime(v.Get("since"))
+	}
+	if err == nil && v.Get("until") != "" {
+		q.Until, err = parseSearchTime(v.Get("until"))
+	}
+	if err == nil && v.Get("limit") != "" {
+		q.Limit, err = strconv.Atoi(v.Get("limit"))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	if v.Get("from") != "" {
+		q.From = v.Get("from")
+	}
+	for _, room := range v["room"] {
+		q.Rooms = append(q.Rooms, apiRoom(room))
+	}
+	// like a search from a connection, only the rooms the caller is in
+	q.scope(a.hub.joined(identity))
+	writeJson(w, a.hub.index.Search(q))
+}
+
+func (a *API) listOnline(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	ret := make([]OnlineUser, 0)
+	for _, c := range a.hub.Connections() {
+		if c.Name == "" {
+			continue
+		}
+		ret = append(ret, OnlineUser{Id: c.Id, Name: c.Name, Rooms: c.Rooms})
+	}
+	writeJson(w, ret)
+}
+
+// identity returns the verified identity of the request, failing it when
+// there is none
+func (a *API) identity(w http.ResponseWriter, r *http.Request) (string, bool) {
+	identity := clientIdentity(r)
+	if identity == "" {
+		http.Error(w, "a client certificate is required", 401)
+		return "", false
+	}
+	return identity, true
+}
+
+// usersEnabled fails the request when unread counts are disabled
+func (a *API) usersEnabled(w http.ResponseWriter) bool {
+	if a.hub.users == nil {
+		http.Error(w, "unread counts are disabled", 501)
+		return false
+	}
+	return true
+}
+
+func (a *API) getUnread(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if a.usersEnabled(w) == false {
+		return
+	}
+	identity, ok := a.identity(w, r)
+	if ok == false {
+		return
+	}
+	unread, err := a.hub.users.Unread(identity)
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	writeJson(w, unread)
+}
+
+func (a *API) markRead(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	if a.usersEnabled(w) == false {
+		return
+	}
+	identity, ok := a.identity(w, r)
+	if ok == false {
+		return
+	}
+	body := &ReadMarker{}
+	if readJson(w, r, body) == false {
+		return
+	}
+	a.hub.users.MarkRead(identity, apiRoom(body.Room), body.MessageId)
+	w.WriteHeader(204)
+}
+
+func (a *API) serveOpenAPI(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeJson(w, a.OpenAPI())
+}
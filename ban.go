@@ -0,0 +1,191 @@
+package webchat
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Ban kinds
+const (
+	BanIP       = "ip"
+	BanName     = "name"
+	BanIdentity = "identity"
+)
+
+// Ban blocks connections by remote ip, name or verified identity
+type Ban struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+func (b Ban) Validate() error {
+	if b.Value == "" {
+		return fmt.Errorf("empty ban value")
+	}
+	switch b.Kind {
+	case BanIP, BanName, BanIdentity:
+		return nil
+	}
+	return fmt.Errorf("invalid ban kind %q", b.Kind)
+}
+
+// ConnectionInfo describes a connection for administration
+type ConnectionInfo struct {
+	Id         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Identity   string    `json:"identity,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	Connected  time.Time `json:"connected"`
+	BytesIn    int64     `json:"bytes_in"`
+	BytesOut   int64     `json:"bytes_out"`
+	Rooms      []string  `json:"rooms"`
+}
+
+// HubStats is a snapshot of hub counters
+type HubStats struct {
+	Connections int   `json:"connections"`
+	Rooms       int   `json:"rooms"`
+	Received    int64 `json:"received"`
+	Sent        int64 `json:"sent"`
+	Dropped     int64 `json:"dropped"`
+}
+
+func (h *Hub) Stats() *HubStats {
+	rooms := len(h.Rooms())
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return &HubStats{
+		Connections: len(h.connections),
+		Rooms:       rooms,
+		Received:    atomic.LoadInt64(&h.received),
+		Sent:        atomic.LoadInt64(&h.sent),
+		Dropped:     atomic.LoadInt64(&h.dropped),
+	}
+}
+
+// Connections returns all registered connections sorted by id
+func (h *Hub) Connections() []*ConnectionInfo {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ret := make([]*ConnectionInfo, 0, len(h.connections))
+	for c := range h.connections {
+		in, out := c.bytes()
+		info := &ConnectionInfo{
+			Id:         c.id,
+			Name:       c.Name,
+			Identity:   c.Identity,
+			RemoteAddr: c.transport.RemoteAddr(),
+			Connected:  c.connected,
+			BytesIn:    in,
+			BytesOut:   out,
+			Rooms:      make([]string, 0, len(c.rooms)),
+		}
+		for room := range c.rooms {
+			info.Rooms = append(info.Rooms, room)
+		}
+		sort.Strings(info.Rooms)
+		ret = append(ret, info)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Id < ret[j].Id })
+	return ret
+}
+
+// Kick disconnects the connection with the given id. It is safe to call from
+// any goroutine, including callbacks.
+func (h *Hub) Kick(id int64) error {
+	h.mx.Lock()
+	c, err := h.findConnection(id)
+	h.mx.Unlock()
+	if err != nil {
+		return err
+	}
+	go func() { h.unregister <- c }()
+	return nil
+}
+
+// AddBan bans future connections and kicks current ones matching b. It
+// returns the number of connections kicked.
+func (h *Hub) AddBan(b Ban) (int, error) {
+	err := b.Validate()
+	if err != nil {
+		return 0, err
+	}
+	h.mx.Lock()
+	h.bans[b] = true
+	h.mx.Unlock()
+
+	kicked := 0
+	for _, c := range h.Connections() {
+		if banMatches(b, c.RemoteAddr, c.Name, c.Identity) {
+			if h.Kick(c.Id) == nil {
+				kicked++
+			}
+		}
+	}
+	return kicked, nil
+}
+
+func (h *Hub) RemoveBan(b Ban) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	delete(h.bans, b)
+}
+
+func (h *Hub) Bans() []Ban {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ret := make([]Ban, 0, len(h.bans))
+	for b := range h.bans {
+		ret = append(ret, b)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Kind != ret[j].Kind {
+			return ret[i].Kind < ret[j].Kind
+		}
+		return ret[i].Value < ret[j].Value
+	})
+	return ret
+}
+
+// banned reports whether c is banned. name overrides the connection name when
+// not empty, ie for a pending nick change.
+func (h *Hub) banned(c *Connection, name string) bool {
+	h.mx.Lock()
+	if name == "" {
+		name = c.Name
+	}
+	h.mx.Unlock()
+	return h.bannedAs(c.transport.RemoteAddr(), name, c.Identity)
+}
+
+// bannedAs reports whether a sender without a connection is banned, ie a
+// rest client
+func (h *Hub) bannedAs(remote, name, identity string) bool {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	for b := range h.bans {
+		if banMatches(b, remote, name, identity) {
+			return true
+		}
+	}
+	return false
+}
+
+func banMatches(b Ban, remote, name, identity string) bool {
+	switch b.Kind {
+	case BanIP:
+		host, _, err := net.SplitHostPort(remote)
+		if err != nil {
+			host = remote
+		}
+		return host == b.Value
+	case BanName:
+		return name != "" && name == b.Value
+	case BanIdentity:
+		return identity != "" && identity == b.Value
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package webchat
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BlobStore stores uploaded files by key. Keys are generated by Uploads and
+// are safe to use as file names.
+type BlobStore interface {
+	// Put stores size bytes from r under key
+	Put(key, contentType string, r io.Reader, size int64) error
+
+	// Get opens the blob stored under key
+	Get(key string) (*Blob, error)
+
+	Delete(key string) error
+}
+
+// Blob is an open blob, it must be closed
+type Blob struct {
+	io.ReadCloser
+	ContentType string
+	Size        int64
+}
+
+// DiskStore keeps blobs as files in a directory. The content type is kept in
+// a file next to each blob.
+type DiskStore struct {
+	dir string
+}
+
+func NewDiskStore(dir string) (*DiskStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *DiskStore) Put(key, contentType string, r io.Reader, size int64) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(s.dir, ".upload")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	n, err := io.Copy(f, r)
+	if err == nil && n != size {
+		err = fmt.Errorf("short write %d of %d bytes", n, size)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = ioutil.WriteFile(path+".type", []byte(contentType), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	return err
+}
+
+func (s *DiskStore) Get(key string) (*Blob, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := ioutil.ReadFile(path + ".type")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Blob{ReadCloser: f, ContentType: string(contentType), Size: st.Size()}, nil
+}
+
+func (s *DiskStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	os.Remove(path + ".type")
+	return os.Remove(path)
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sigmonsays/webchat"
+)
+
+// Report is the result of a benchmark run
+type Report struct {
+	Started         time.Time `json:"started"`
+	URL             string    `json:"url"`
+	Clients         int       `json:"clients"`
+	Rate            float64   `json:"rate"`
+	Duration        string    `json:"duration"`
+	Connected       int64     `json:"connected"`
+	ConnectFailures int64     `json:"connect_failures"`
+	Disconnects     int64     `json:"disconnects"`
+	Sent            int64     `json:"sent"`
+	Expected        int64     `json:"expected"`
+	Received        int64     `json:"received"`
+	Loss            float64   `json:"loss"`
+	Latency         Latency   `json:"latency_ms"`
+}
+
+// Latency holds fan-out latency percentiles in milliseconds
+type Latency struct {
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type bench struct {
+	url   string
+	room  string
+	runId string
+
+	// number of clients connected, sampled for every message sent
+	live int64
+
+	sent            int64
+	expected        int64
+	received        int64
+	connected       int64
+	connectFailures int64
+	disconnects     int64
+
+	mx        sync.Mutex
+	latencies []time.Duration
+}
+
+// body encodes the sender and send time so receivers can measure latency
+func (b *bench) body(client, seq int) string {
+	return fmt.Sprintf("bench %s %d %d %d", b.runId, client, seq, time.Now().UnixNano())
+}
+
+func (b *bench) parse(text string) (time.Time, bool) {
+	fields := strings.Fields(text)
+	if len(fields) != 5 || fields[0] != "bench" || fields[1] != b.runId {
+		return time.Time{}, false
+	}
+	ns, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+func (b *bench) client(id int, rate float64, stop, sendStop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ws, _, err := websocket.DefaultDialer.Dial(b.url, nil)
+	if err != nil {
+		atomic.AddInt64(&b.connectFailures, 1)
+		log.Printf("client %d: %s", id, err)
+		return
+	}
+	atomic.AddInt64(&b.connected, 1)
+	defer ws.Close()
+
+	var wmx sync.Mutex
+	write := func(m *webchat.Message) error {
+		wmx.Lock()
+		defer wmx.Unlock()
+		ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return ws.WriteMessage(websocket.TextMessage, m.Json())
+	}
+	nick := fmt.Sprintf("bench%d", id)
+	err = write(&webchat.Message{Op: webchat.JoinOp, Room: b.room})
+	if err == nil {
+		err = write(&webchat.Message{Op: webchat.NickOp, From: nick})
+	}
+	if err != nil {
+		atomic.AddInt64(&b.disconnects, 1)
+		return
+	}
+	atomic.AddInt64(&b.live, 1)
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		latencies := make([]time.Duration, 0, 1024)
+		defer func() {
+			b.mx.Lock()
+			b.latencies = append(b.latencies, latencies...)
+			b.mx.Unlock()
+		}()
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				select {
+				case <-stop:
+				default:
+					atomic.AddInt64(&b.disconnects, 1)
+				}
+				return
+			}
+			m := &webchat.Message{}
+			if m.FromJson(data) != nil || m.Op != webchat.MessageOp {
+				continue
+			}
+			sentAt, ok := b.parse(m.Message)
+			if ok == false {
+				continue
+			}
+			atomic.AddInt64(&b.received, 1)
+			latencies = append(latencies, time.Since(sentAt))
+		}
+	}()
+
+	if rate > 0 {
+		// spread clients out so they do not send in lock step
+		interval := time.Duration(float64(time.Second) / rate)
+		time.Sleep(time.Duration(rand.Int63n(int64(interval))))
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		seq := 0
+	send:
+		for {
+			select {
+			case <-sendStop:
+				break send
+			case <-readDone:
+				break send
+			case <-ticker.C:
+			}
+			seq++
+			m := &webchat.Message{Op: webchat.MessageOp, From: nick, Room: b.room, Message: b.body(id, seq)}
+			live := atomic.LoadInt64(&b.live)
+			if write(m) != nil {
+				break send
+			}
+			atomic.AddInt64(&b.sent, 1)
+			atomic.AddInt64(&b.expected, live)
+		}
+	}
+
+	select {
+	case <-stop:
+	case <-readDone:
+	}
+	atomic.AddInt64(&b.live, -1)
+	ws.Close()
+	<-readDone
+}
+
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return ms(sorted[i])
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func main() {
+	url := flag.String("url", "ws://localhost:8080/ws", "websocket url of the chat server")
+	clients := flag.Int("clients", 100, "number of simulated clients")
+	rate := flag.Float64("rate", 1, "messages per second sent by each client")
+	duration := flag.Duration("duration", 30*time.Second, "how long clients send messages")
+	ramp := flag.Duration("ramp", 10*time.Millisecond, "delay between starting clients")
+	drain := flag.Duration("drain", 5*time.Second, "time to wait for messages in flight after sending stops")
+	room := flag.String("room", "bench", "room to send messages to")
+	jsonOut := flag.String("json", "", "write a json report to this file, - for stdout")
+	flag.Parse()
+
+	b := &bench{
+		url:   *url,
+		room:  *room,
+		runId: strconv.FormatInt(time.Now().UnixNano(), 36),
+	}
+	r := &Report{
+		Started:  time.Now(),
+		URL:      *url,
+		Clients:  *clients,
+		Rate:     *rate,
+		Duration: duration.String(),
+	}
+
+	stop := make(chan struct{})
+	sendStop := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < *clients; i++ {
+		wg.Add(1)
+		go b.client(i, *rate, stop, sendStop, wg)
+		time.Sleep(*ramp)
+	}
+	log.Printf("started %d clients, connected:%d failures:%d", *clients,
+		atomic.LoadInt64(&b.connected), atomic.LoadInt64(&b.connectFailures))
+
+	time.Sleep(*duration)
+	close(sendStop)
+	time.Sleep(*drain)
+	close(stop)
+	wg.Wait()
+
+	r.Connected = b.connected
+	r.ConnectFailures = b.connectFailures
+	r.Disconnects = b.disconnects
+	r.Sent = b.sent
+	r.Expected = b.expected
+	r.Received = b.received
+	if r.Expected > 0 {
+		r.Loss = float64(r.Expected-r.Received) / float64(r.Expected)
+	}
+	sort.Slice(b.latencies, func(i, j int) bool { return b.latencies[i] < b.latencies[j] })
+	if n := len(b.latencies); n > 0 {
+		var total time.Duration
+		for _, d := range b.latencies {
+			total += d
+		}
+		r.Latency = Latency{
+			Mean: ms(total / time.Duration(n)),
+			P50:  percentile(b.latencies, 0.50),
+			P90:  percentile(b.latencies, 0.90),
+			P99:  percentile(b.latencies, 0.99),
+			Max:  ms(b.latencies[n-1]),
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "clients:%d connected:%d connect_failures:%d disconnects:%d\n",
+		r.Clients, r.Connected, r.ConnectFailures, r.Disconnects)
+	fmt.Fprintf(os.Stderr, "sent:%d expected:%d received:%d loss:%.2f%%\n",
+		r.Sent, r.Expected, r.Received, r.Loss*100)
+	fmt.Fprintf(os.Stderr, "latency ms mean:%.2f p50:%.2f p90:%.2f p99:%.2f max:%.2f\n",
+		r.Latency.Mean, r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+
+	if *jsonOut == "" {
+		return
+	}
+	data, _ := json.MarshalIndent(r, "", "  ")
+	data = append(data, '\n')
+	if *jsonOut == "-" {
+		os.Stdout.Write(data)
+		return
+	}
+	err := ioutil.WriteFile(*jsonOut, data, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
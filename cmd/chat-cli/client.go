@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sigmonsays/webchat"
+)
+
+const (
+	maxBackoff = 30 * time.Second
+	writeWait  = 10 * time.Second
+)
+
+// client is a websocket chat client that reconnects when the connection drops
+type client struct {
+	url  string
+	room string
+
+	// codec asked for, json is used when the server does not know it
+	codec webchat.Codec
+
+	mx      sync.Mutex
+	nick    string
+	ws      *websocket.Conn
+	wsCodec webchat.Codec
+}
+
+func (c *client) Nick() string {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.nick
+}
+
+// features of the protocol the client renders, it is not sent receipts,
+// previews and the like
+var features = []string{webchat.FeatureRooms, webchat.FeatureEdits, webchat.FeatureThreads, webchat.FeatureReactions}
+
+// connect dials the server and joins the room. It returns the codec the
+// server agreed to.
+func (c *client) connect() (*websocket.Conn, webchat.Codec, error) {
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{c.codec.Name()}
+	dialer.EnableCompression = true
+	ws, _, err := dialer.Dial(c.url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var codec webchat.Codec = webchat.JSONCodec{}
+	if ws.Subprotocol() == c.codec.Name() {
+		codec = c.codec
+	}
+	c.mx.Lock()
+	c.ws = ws
+	c.wsCodec = codec
+	nick := c.nick
+	c.mx.Unlock()
+
+	err = c.Send(&webchat.Message{Op: webchat.HelloOp, Version: webchat.ProtocolVersion, Features: features})
+	if err == nil {
+		err = c.Send(&webchat.Message{Op: webchat.JoinOp, From: nick, Room: c.room})
+	}
+	if err == nil && nick != "" {
+		err = c.Send(&webchat.Message{Op: webchat.NickOp, From: nick})
+	}
+	if err != nil {
+		ws.Close()
+		return nil, nil, err
+	}
+	return ws, codec, nil
+}
+
+// Run connects and delivers messages to onMessage, reconnecting with backoff
+// until stop is closed
+func (c *client) Run(onMessage func(*webchat.Message), onStatus func(string), stop chan struct{}) {
+	backoff := time.Second
+	for {
+		onStatus("connecting")
+		ws, codec, err := c.connect()
+		if err != nil {
+			onStatus(fmt.Sprintf("connect failed: %s, retry in %s", err, backoff))
+			select {
+			case <-time.After(backoff):
+			case <-stop:
+				return
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+		backoff = time.Second
+		onStatus("connected")
+
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-stop:
+				ws.Close()
+			case <-done:
+			}
+		}()
+		err = c.readLoop(ws, codec, onMessage)
+		close(done)
+		c.mx.Lock()
+		c.ws = nil
+		c.mx.Unlock()
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		onStatus(fmt.Sprintf("disconnected: %s", err))
+	}
+}
+
+func (c *client) readLoop(ws *websocket.Conn, codec webchat.Codec, onMessage func(*webchat.Message)) error {
+	defer ws.Close()
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+		m := &webchat.Message{}
+		err = codec.Unmarshal(data, m)
+		if err != nil {
+			log.Printf("%s: %s", codec.Name(), err)
+			continue
+		}
+		onMessage(m)
+	}
+}
+
+func (c *client) Send(m *webchat.Message) error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if c.ws == nil {
+		return fmt.Errorf("not connected")
+	}
+	data, err := c.wsCodec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	mt := websocket.TextMessage
+	if c.wsCodec.Binary() {
+		mt = websocket.BinaryMessage
+	}
+	c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.ws.WriteMessage(mt, data)
+}
+
+func (c *client) SendText(text string) error {
+	return c.Send(&webchat.Message{Op: webchat.MessageOp, From: c.Nick(), Message: text, Room: c.room})
+}
+
+func (c *client) SetNick(nick string) error {
+	c.mx.Lock()
+	c.nick = nick
+	c.mx.Unlock()
+	return c.Send(&webchat.Message{Op: webchat.NickOp, From: nick})
+}
+
+// Close closes the connection cleanly
+func (c *client) Close() {
+	c.CloseWait(nil)
+}
+
+// CloseWait sends a close message and waits up to writeWait for done, the
+// end of the read loop, before closing. The server answers the close once it
+// has read what was sent before it, closing first may reset the connection
+// and lose those messages.
+func (c *client) CloseWait(done <-chan error) {
+	c.mx.Lock()
+	ws := c.ws
+	c.ws = nil
+	c.mx.Unlock()
+	if ws == nil {
+		return
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(writeWait):
+		}
+	}
+	ws.Close()
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sigmonsays/webchat"
+)
+
+const (
+	// matches HistoryOp in cmd/chat
+	HistoryOp = iota + 100
+)
+
+func formatMessage(m *webchat.Message) string {
+	var text string
+	switch m.Op {
+	case webchat.MessageOp, HistoryOp:
+		text = fmt.Sprintf("<%s> %s", m.From, m.Message)
+		if m.ReplyTo != 0 {
+			text = fmt.Sprintf("<%s> (reply) %s", m.From, m.Message)
+		}
+		if m.Edited != 0 {
+			text += " (edited)"
+		}
+		for emoji, users := range m.Reactions {
+			text += fmt.Sprintf(" [%s %d]", emoji, len(users))
+		}
+	case webchat.EditOp:
+		text = fmt.Sprintf("<%s> (edited) %s", m.From, m.Message)
+	case webchat.ReactionOp:
+		if m.Remove {
+			return ""
+		}
+		text = fmt.Sprintf("-!- %s reacted with %s", m.From, m.Message)
+	case webchat.DeleteOp:
+		text = fmt.Sprintf("-!- a message from %s was deleted", m.From)
+	case webchat.NoticeOp:
+		text = "-!- " + m.Message
+	case webchat.TopicOp:
+		text = fmt.Sprintf("-!- %s set the topic to %s", m.From, m.Message)
+	case webchat.JoinOp, webchat.PartOp:
+		if m.Room == webchat.DefaultRoom {
+			// the server sends a notice for the default room
+			return ""
+		}
+		verb := "joined"
+		if m.Op == webchat.PartOp {
+			verb = "left"
+		}
+		text = fmt.Sprintf("-!- %s %s", m.From, verb)
+	default:
+		return ""
+	}
+	if m.Room != webchat.DefaultRoom {
+		text = "#" + m.Room + " " + text
+	}
+	// other users may not send escape sequences to the terminal
+	return time.Now().Format("15:04") + " " + webchat.StripControl(text)
+}
+
+func main() {
+	url := flag.String("url", "ws://localhost:8080/ws", "websocket url of the chat server")
+	room := flag.String("room", "", "room to join, the default room if empty")
+	nick := flag.String("nick", os.Getenv("USER"), "nick name")
+	follow := flag.Bool("follow", false, "when not interactive, keep printing messages after stdin is consumed")
+	protocol := flag.String("protocol", "json", "wire encoding, json or msgpack")
+	flag.Parse()
+
+	c := &client{
+		url:  *url,
+		room: *room,
+		nick: *nick,
+	}
+	switch *protocol {
+	case "json":
+		c.codec = webchat.JSONCodec{}
+	case "msgpack":
+		c.codec = webchat.MsgpackCodec{}
+	default:
+		log.Fatalf("unknown protocol %s", *protocol)
+	}
+
+	if isTerminal(int(os.Stdin.Fd())) == false || isTerminal(int(os.Stdout.Fd())) == false {
+		err := runBatch(c, *follow)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	err := runInteractive(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runBatch sends each line of stdin as a message, ie echo hi | chat-cli
+func runBatch(c *client, follow bool) error {
+	ws, codec, err := c.connect()
+	if err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- c.readLoop(ws, codec, func(m *webchat.Message) {
+			if follow {
+				if s := formatMessage(m); s != "" {
+					fmt.Println(s)
+				}
+			}
+		})
+	}()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		err = c.SendText(line)
+		if err != nil {
+			return err
+		}
+	}
+	if follow {
+		return <-done
+	}
+	c.CloseWait(done)
+	return scanner.Err()
+}
+
+func runInteractive(c *client) error {
+	fd := int(os.Stdin.Fd())
+	rows, cols, err := getSize(fd)
+	if err != nil {
+		return err
+	}
+	state, err := makeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer restore(fd, state)
+
+	// log lines would corrupt the screen
+	log.SetOutput(ioutil.Discard)
+
+	ui := newTui(os.Stdout, rows, cols)
+	ui.Enter()
+	defer ui.Leave()
+
+	resize := make(chan os.Signal, 1)
+	notifyResize(resize)
+	go func() {
+		for range resize {
+			rows, cols, err := getSize(fd)
+			if err == nil {
+				ui.Resize(rows, cols)
+			}
+		}
+	}()
+
+	room := c.room
+	if room == "" {
+		room = "default"
+	}
+	onStatus := func(s string) {
+		ui.SetStatus(fmt.Sprintf(" %s | room %s | nick %s | %s", c.url, room, c.Nick(), s))
+	}
+	onMessage := func(m *webchat.Message) {
+		if s := formatMessage(m); s != "" {
+			ui.Add(s)
+		}
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go c.Run(onMessage, onStatus, stop)
+
+	in := bufio.NewReader(os.Stdin)
+	for {
+		r, _, err := in.ReadRune()
+		if err != nil {
+			return err
+		}
+		switch r {
+		case 0x03, 0x04:
+			c.Close()
+			return nil
+		case 0x0c:
+			ui.Redraw()
+			continue
+		case 0x1b:
+			// page up and page down scroll, other escape sequences are ignored
+			seq := readEscape(in)
+			if seq == "[5~" {
+				ui.Scroll(1)
+			} else if seq == "[6~" {
+				ui.Scroll(-1)
+			}
+			continue
+		}
+
+		line, ok := ui.Key(r)
+		if ok == false || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/") {
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "/quit":
+				c.Close()
+				return nil
+			case "/nick":
+				if len(fields) != 2 {
+					ui.Add("-!- usage: /nick <name>")
+					continue
+				}
+				err = c.SetNick(fields[1])
+				onStatus("connected")
+			default:
+				ui.Add("-!- commands: /nick <name>, /quit")
+				continue
+			}
+		} else {
+			err = c.SendText(line)
+		}
+		if err != nil {
+			ui.Add("-!- " + err.Error())
+		}
+	}
+}
+
+// readEscape reads the rest of an escape sequence after ESC
+func readEscape(in *bufio.Reader) string {
+	r, _, err := in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+	seq := string(r)
+	for {
+		r, _, err = in.ReadRune()
+		if err != nil {
+			return seq
+		}
+		seq += string(r)
+		if r >= '@' && r <= '~' {
+			return seq
+		}
+	}
+}
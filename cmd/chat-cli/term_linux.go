@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"unsafe"
+)
+
+type termState struct {
+	termios syscall.Termios
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+func isTerminal(fd int) bool {
+	var termios syscall.Termios
+	return ioctl(fd, syscall.TCGETS, unsafe.Pointer(&termios)) == nil
+}
+
+// makeRaw puts the terminal in raw mode, returning the state to restore
+func makeRaw(fd int) (*termState, error) {
+	old := &termState{}
+	err := ioctl(fd, syscall.TCGETS, unsafe.Pointer(&old.termios))
+	if err != nil {
+		return nil, err
+	}
+	raw := old.termios
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	err = ioctl(fd, syscall.TCSETS, unsafe.Pointer(&raw))
+	if err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+func restore(fd int, state *termState) error {
+	return ioctl(fd, syscall.TCSETS, unsafe.Pointer(&state.termios))
+}
+
+func getSize(fd int) (rows, cols int, err error) {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	err = ioctl(fd, syscall.TIOCGWINSZ, unsafe.Pointer(&ws))
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Row), int(ws.Col), nil
+}
+
+// notifyResize sends on ch when the terminal is resized
+func notifyResize(ch chan os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
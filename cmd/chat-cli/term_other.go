@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// the interactive ui is only supported on linux, elsewhere chat-cli runs non
+// interactively
+
+type termState struct{}
+
+func isTerminal(fd int) bool {
+	return false
+}
+
+func makeRaw(fd int) (*termState, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func restore(fd int, state *termState) error {
+	return nil
+}
+
+func getSize(fd int) (rows, cols int, err error) {
+	return 0, 0, fmt.Errorf("not supported")
+}
+
+func notifyResize(ch chan os.Signal) {
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// maximum number of scrollback lines kept
+const maxScrollback = 5000
+
+// tui draws the scrollback, a status line and an input line with ansi escapes
+type tui struct {
+	mx     sync.Mutex
+	out    io.Writer
+	rows   int
+	cols   int
+	lines  []string
+	scroll int
+	status string
+	input  []rune
+}
+
+func newTui(out io.Writer, rows, cols int) *tui {
+	return &tui{
+		out:  out,
+		rows: rows,
+		cols: cols,
+	}
+}
+
+// Enter switches to the alternate screen
+func (t *tui) Enter() {
+	io.WriteString(t.out, "\x1b[?1049h")
+	t.Redraw()
+}
+
+// Leave restores the normal screen
+func (t *tui) Leave() {
+	io.WriteString(t.out, "\x1b[?1049l")
+}
+
+func (t *tui) Resize(rows, cols int) {
+	t.mx.Lock()
+	t.rows, t.cols = rows, cols
+	t.mx.Unlock()
+	t.Redraw()
+}
+
+func (t *tui) Add(line string) {
+	t.mx.Lock()
+	for _, l := range strings.Split(line, "\n") {
+		t.lines = append(t.lines, l)
+		if t.scroll > 0 {
+			// keep the view still while scrolled back
+			t.scroll++
+		}
+	}
+	if len(t.lines) > maxScrollback {
+		t.lines = t.lines[len(t.lines)-maxScrollback:]
+	}
+	t.mx.Unlock()
+	t.Redraw()
+}
+
+func (t *tui) SetStatus(status string) {
+	t.mx.Lock()
+	t.status = status
+	t.mx.Unlock()
+	t.Redraw()
+}
+
+// Scroll moves the view by n pages, positive is back in time
+func (t *tui) Scroll(n int) {
+	t.mx.Lock()
+	t.scroll += n * (t.rows - 2)
+	if limit := len(t.wrapped()) - (t.rows - 2); t.scroll > limit {
+		t.scroll = limit
+	}
+	if t.scroll < 0 {
+		t.scroll = 0
+	}
+	t.mx.Unlock()
+	t.Redraw()
+}
+
+// wrapped returns the scrollback wrapped to the terminal width
+func (t *tui) wrapped() []string {
+	ret := make([]string, 0, len(t.lines))
+	for _, line := range t.lines {
+		r := []rune(line)
+		for len(r) > t.cols && t.cols > 0 {
+			ret = append(ret, string(r[:t.cols]))
+			r = r[t.cols:]
+		}
+		ret = append(ret, string(r))
+	}
+	return ret
+}
+
+func (t *tui) Redraw() {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	height := t.rows - 2
+	if height < 1 {
+		return
+	}
+	lines := t.wrapped()
+	end := len(lines) - t.scroll
+	if end < 0 {
+		end = 0
+	}
+	start := end - height
+	if start < 0 {
+		start = 0
+	}
+	visible := lines[start:end]
+
+	var buf bytes.Buffer
+	buf.WriteString("\x1b[H")
+	for i := 0; i < height; i++ {
+		buf.WriteString("\x1b[K")
+		if i < len(visible) {
+			buf.WriteString(visible[i])
+		}
+		buf.WriteString("\r\n")
+	}
+
+	status := t.status
+	if t.scroll > 0 {
+		status = fmt.Sprintf("%s [scrolled %d]", status, t.scroll)
+	}
+	if r := []rune(status); len(r) > t.cols {
+		status = string(r[:t.cols])
+	}
+	buf.WriteString("\x1b[7m\x1b[K")
+	buf.WriteString(status)
+	buf.WriteString(strings.Repeat(" ", t.cols-len([]rune(status))))
+	buf.WriteString("\x1b[0m\r\n")
+
+	// show the tail of the input when it is wider than the screen
+	input := t.input
+	if width := t.cols - 3; len(input) > width && width > 0 {
+		input = input[len(input)-width:]
+	}
+	buf.WriteString("\x1b[K> ")
+	buf.WriteString(string(input))
+	t.out.Write(buf.Bytes())
+}
+
+// Key applies a key press to the input line, returning a submitted line
+func (t *tui) Key(r rune) (string, bool) {
+	t.mx.Lock()
+	var line string
+	submit := false
+	switch {
+	case r == '\r' || r == '\n':
+		line = string(t.input)
+		t.input = t.input[:0]
+		t.scroll = 0
+		submit = true
+	case r == 0x7f || r == 0x08:
+		if len(t.input) > 0 {
+			t.input = t.input[:len(t.input)-1]
+		}
+	case r == 0x15:
+		t.input = t.input[:0]
+	case r >= ' ':
+		t.input = append(t.input, r)
+	}
+	t.mx.Unlock()
+	t.Redraw()
+	return line, submit
+}
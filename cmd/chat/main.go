@@ -1,15 +1,17 @@
 package main
 
 import (
-	"container/list"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"sync"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/sigmonsays/webchat"
 )
@@ -19,11 +21,37 @@ const (
 )
 
 var addr = flag.String("addr", ":8080", "http service address")
+var tlsCert = flag.String("tls-cert", "", "tls certificate file, enables https")
+var tlsKey = flag.String("tls-key", "", "tls key file")
+var tlsClientCA = flag.String("tls-client-ca", "", "CA file used to verify optional client certificates")
+var redirectAddr = flag.String("http-redirect", "", "listen address that redirects http to https, ie :80")
+var lineAddr = flag.String("line-addr", "", "listen address for the line protocol gateway, ie :2323")
+var ircAddr = flag.String("irc-addr", "", "listen address for the irc server, ie :6667")
+var sshAddr = flag.String("ssh-addr", "", "listen address for the ssh server, ie :2222")
+var sshHostKey = flag.String("ssh-host-key", "ssh_host_ed25519_key", "ssh host key, generated if missing")
+var sshAuthorizedKeys = flag.String("ssh-authorized-keys", "", "authorized_keys file of the keys that may log in with ssh, required with -ssh-addr")
+var adminAddr = flag.String("admin-addr", "", "listen address for the admin api, ie 127.0.0.1:8081")
+var adminToken = flag.String("admin-token", os.Getenv("CHAT_ADMIN_TOKEN"), "bearer token required by the admin api")
+var historySize = flag.Int("history", 100, "number of messages kept per room for replay and the rest api")
+var searchSize = flag.Int("search-size", 100000, "number of messages in the search index, 0 disables search")
+var moderators = flag.String("moderators", "", "comma separated client identities that may edit and delete any message")
+var offlineQueue = flag.Int("offline-queue", 100, "messages kept per identified user while offline, 0 disables offline queues and unread counts")
+var uploadDir = flag.String("upload-dir", "", "directory uploads are kept in, enables attachments")
+var uploadS3 = flag.String("upload-s3", "", "s3 compatible endpoint and bucket uploads are kept in, ie http://minio:9000/chat. keys are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+var uploadS3Region = flag.String("upload-s3-region", "us-east-1", "region of the -upload-s3 bucket")
+var uploadMaxSize = flag.Int64("upload-max-size", 10<<20, "largest upload in bytes")
+var uploadTypes = flag.String("upload-types", "image/,text/plain,application/pdf,application/zip,application/x-gzip", "comma separated media types that may be uploaded, a trailing / allows all subtypes")
+var uploadURL = flag.String("upload-url", "", "public url of the server prepended to attachment links, ie https://chat.example.com")
+var previews = flag.Bool("previews", true, "fetch previews of links in messages from public addresses")
+var compression = flag.Bool("compression", true, "compress websocket messages with permessage-deflate when the client offers it")
+var compressionLevel = flag.Int("compression-level", 1, "flate compression level, 1 is fastest and 9 smallest")
+var compressionThreshold = flag.Int("compression-threshold", 256, "websocket messages shorter than this many bytes are sent uncompressed")
+var allowedOrigins = flag.String("allowed-origins", "", "comma separated origins allowed to connect, ie *.example.com")
 
 type chatHandler struct {
-	mx        sync.Mutex
 	staticDir string
-	history   *list.List
+	history   webchat.History
+	index     *webchat.SearchIndex
 }
 
 func (h *chatHandler) serveHome(w http.ResponseWriter, r *http.Request) {
@@ -38,38 +66,125 @@ func (h *chatHandler) serveHome(w http.ResponseWriter, r *http.Request) {
 	homeTempl.Execute(w, r.Host)
 }
 
-func (h *chatHandler) addHistory(m *webchat.Message) {
-	h.mx.Lock()
-	defer h.mx.Unlock()
-	h.history.PushBack(m)
-	if h.history.Len() > 5 {
-		if e := h.history.Front(); e != nil {
-			h.history.Remove(e)
+// purgeHistory removes history and search results for room, or everything
+// when room is *
+func (h *chatHandler) purgeHistory(room string) (int, error) {
+	if h.index != nil {
+		h.index.Purge(room)
+	}
+	return h.history.Purge(room)
+}
+
+// modifyMessage applies an EditOp or DeleteOp from c to the history and
+// tells the room
+func (h *chatHandler) modifyMessage(op webchat.OpCode, hub *webchat.Hub, c *webchat.Connection, m *webchat.Message) error {
+	original, err := h.history.Get(m.Room, m.MessageId)
+	if err == nil && original.Deleted {
+		err = fmt.Errorf("message %d was deleted", m.MessageId)
+	}
+	if err == nil && hub.MayModify(c, original) == false {
+		err = fmt.Errorf("you may only change your own messages")
+	}
+	if err == nil && op == webchat.EditOp && strings.TrimSpace(m.Message) == "" {
+		err = fmt.Errorf("use delete to remove a message")
+	}
+	if err != nil {
+		hub.SendMessage(c, &webchat.Message{Op: webchat.NoticeOp, Room: m.Room, Message: err.Error()})
+		return err
+	}
+
+	changed := *original
+	changed.Edited = m.Time
+	if op == webchat.EditOp {
+		changed.Message = m.Message
+		changed.Html = m.Html
+	} else {
+		changed.Message = ""
+		changed.Html = ""
+		changed.Deleted = true
+	}
+	err = h.history.Update(&changed)
+	if err != nil {
+		return err
+	}
+	if h.index != nil {
+		if op == webchat.EditOp {
+			h.index.Update(&changed)
+		} else {
+			h.index.Remove(changed.MessageId)
 		}
 	}
+	log.Printf("%s message %d by %s", op, m.MessageId, c.Name)
+
+	notify := changed
+	notify.Op = op
+	hub.SendBroadcast(&notify)
+	return nil
 }
 
-func (h *chatHandler) getHistory() []*webchat.Message {
-	h.mx.Lock()
-	defer h.mx.Unlock()
-	ret := make([]*webchat.Message, 0)
-	for e := h.history.Front(); e != nil; e = e.Next() {
-		ret = append(ret, e.Value.(*webchat.Message))
+// notifyThread tells everyone who took part in the thread of reply, except
+// its author
+func (h *chatHandler) notifyThread(hub *webchat.Hub, reply *webchat.Message) {
+	messages, err := h.history.Thread(reply.Room, reply.ReplyTo)
+	if err != nil {
+		return
+	}
+	participants := make(map[string]bool)
+	for _, m := range messages {
+		if m.Author != "" && m.Author != reply.Author {
+			participants[m.Author] = true
+		}
+	}
+	notice := &webchat.Message{
+		Op:      webchat.NoticeOp,
+		Room:    reply.Room,
+		From:    reply.From,
+		ReplyTo: reply.ReplyTo,
+		Message: fmt.Sprintf("%s replied in a thread you are in: %s", reply.From, reply.Message),
+	}
+	for author := range participants {
+		for _, c := range hub.AuthorConnections(author) {
+			hub.SendMessage(c, notice)
+		}
 	}
-	return ret
 }
 
 func (h *chatHandler) handleMessage(op webchat.OpCode, hub *webchat.Hub, c *webchat.Connection, m *webchat.Message) error {
 	log.Printf("handleMessage op:%s\n", op)
 
 	if op == webchat.MessageOp {
+		if m.ReplyTo != 0 {
+			root, err := h.history.Get(m.Room, m.ReplyTo)
+			if err != nil {
+				if c != nil {
+					hub.SendMessage(c, &webchat.Message{Op: webchat.NoticeOp, Room: m.Room, Message: "thread not found"})
+				}
+				return err
+			}
+			if root.ReplyTo != 0 {
+				// replies to replies go to the same thread
+				m.ReplyTo = root.ReplyTo
+			}
+		}
 		hub.SendBroadcast(m)
+		err := h.history.Add(m)
+		if err != nil {
+			log.Printf("history Add: %s", err)
+		}
+		if m.ReplyTo != 0 {
+			h.notifyThread(hub, m)
+		}
 
 	} else if op == webchat.RegisterOp {
-		// play back history
-		for _, hm := range h.getHistory() {
-			hm.Op = HistoryOp
-			hub.SendMessage(c, hm)
+		// play back history of the default room
+		messages, err := h.history.Messages(webchat.DefaultRoom, 0, 5)
+		if err != nil {
+			log.Printf("history Messages: %s", err)
+		}
+		for _, hm := range messages {
+			replay := *hm
+			replay.Op = HistoryOp
+			hub.SendMessage(c, &replay)
 		}
 	} else if op == webchat.UnregisterOp {
 		hub.Send(webchat.NoticeOp, fmt.Sprintf("%s has left", c.Name))
@@ -83,10 +198,57 @@ func (h *chatHandler) handleMessage(op webchat.OpCode, hub *webchat.Hub, c *webc
 		} else {
 			hub.Send(webchat.NoticeOp, fmt.Sprintf("%s has changed their name to %s", c.Name, m.From))
 		}
-		c.Name = m.From
 
 	} else if op == webchat.NoticeOp {
 		hub.SendBroadcast(m)
+
+	} else if op == webchat.ThreadOp {
+		if hub.InRoom(c, m.Room) == false {
+			return fmt.Errorf("thread request for a room %s not joined", m.Room)
+		}
+		messages, err := h.history.Thread(m.Room, m.ReplyTo)
+		if err != nil {
+			hub.SendMessage(c, &webchat.Message{Op: webchat.NoticeOp, Room: m.Room, Message: "thread not found"})
+			return err
+		}
+		hub.SendMessage(c, &webchat.Message{Op: webchat.ThreadOp, Room: m.Room, ReplyTo: m.ReplyTo, Results: messages})
+
+	} else if op == webchat.ReactionOp {
+		err := webchat.ValidReaction(m.Message)
+		if err == nil && c.Name == "" {
+			err = fmt.Errorf("set a nick before reacting")
+		}
+		var reactions map[string][]string
+		changed := false
+		if err == nil {
+			reactions, changed, err = h.history.React(m.Room, m.MessageId, m.Message, m.Author, c.Name, m.Remove)
+		}
+		if err != nil {
+			hub.SendMessage(c, &webchat.Message{Op: webchat.NoticeOp, Room: m.Room, Message: err.Error()})
+			return err
+		}
+		if changed {
+			// the reactions of the message replace what clients have, a
+			// nick may have reacted under another name
+			m.From = c.Name
+			m.Reactions = reactions
+			hub.SendBroadcast(m)
+		}
+
+	} else if op == webchat.PreviewOp {
+		err := h.history.SetPreview(m.Room, m.MessageId, m.Preview)
+		if err != nil {
+			return err
+		}
+		hub.SendBroadcast(m)
+
+	} else if op == webchat.EditOp || op == webchat.DeleteOp {
+		return h.modifyMessage(op, hub, c, m)
+
+	} else if op == webchat.JoinOp || op == webchat.PartOp || op == webchat.TopicOp {
+		if m.From != "" {
+			hub.SendBroadcast(m)
+		}
 	} else {
 		log.Printf("Unhandled op %+v\n", m)
 	}
@@ -105,16 +267,70 @@ func main() {
 
 	flag.Parse()
 
+	err := webchat.RegisterOpCode("HistoryOp", HistoryOp, nil)
+	if err != nil {
+		log.Fatal("RegisterOpCode: ", err)
+	}
+
 	hub := webchat.NewHub()
 
 	srv, err := webchat.NewHandler(hub)
 	if err != nil {
 		log.Fatal("NewHandler: ", err)
 	}
+	if *compressionLevel < -2 || *compressionLevel > 9 {
+		log.Fatal("-compression-level must be between -2 and 9")
+	}
+	srv.EnableCompression = *compression
+	srv.CompressionLevel = *compressionLevel
+	srv.CompressionThreshold = *compressionThreshold
+	if *allowedOrigins != "" {
+		srv.AllowedOrigins = strings.Split(*allowedOrigins, ",")
+	}
 
 	handler := &chatHandler{
 		staticDir: staticDir,
-		history:   list.New(),
+		history:   webchat.NewMemoryHistory(*historySize),
+	}
+
+	if *offlineQueue > 0 {
+		hub.SetUsers(webchat.NewUsers(handler.history, *offlineQueue))
+	}
+
+	if *moderators != "" {
+		hub.SetModerators(strings.Split(*moderators, ","))
+	}
+
+	if *searchSize > 0 {
+		handler.index = webchat.NewSearchIndex(*searchSize)
+		hub.SetSearchIndex(handler.index)
+	}
+
+	if *previews {
+		hub.SetPreviewer(webchat.NewPreviewer())
+	}
+
+	var store webchat.BlobStore
+	if *uploadS3 != "" {
+		u, err := url.Parse(*uploadS3)
+		if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			log.Fatal("-upload-s3 must be an endpoint url with a bucket path")
+		}
+		bucket := strings.Trim(u.Path, "/")
+		u.Path = ""
+		store = webchat.NewS3Store(u.String(), *uploadS3Region, bucket, os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"))
+		log.Printf("keeping uploads in s3 bucket %s at %s", bucket, u.Host)
+	} else if *uploadDir != "" {
+		store, err = webchat.NewDiskStore(*uploadDir)
+		if err != nil {
+			log.Fatal("NewDiskStore: ", err)
+		}
+		log.Printf("keeping uploads in %s", *uploadDir)
+	}
+	if store != nil {
+		uploads := webchat.NewUploads(store, *uploadMaxSize, strings.Split(*uploadTypes, ","))
+		uploads.BaseURL = strings.TrimRight(*uploadURL, "/")
+		hub.SetUploads(uploads)
 	}
 
 	opcodes := []webchat.OpCode{
@@ -123,23 +339,111 @@ func main() {
 		webchat.NoticeOp,
 		webchat.NickOp,
 		webchat.MessageOp,
+		webchat.JoinOp,
+		webchat.PartOp,
+		webchat.TopicOp,
+		webchat.EditOp,
+		webchat.DeleteOp,
+		webchat.ThreadOp,
+		webchat.ReactionOp,
+		webchat.PreviewOp,
 	}
 	for _, op := range opcodes {
 		hub.OnCallback(op, handler.handleMessage)
 	}
 	go hub.Start()
 
+	if *lineAddr != "" {
+		l, err := net.Listen("tcp", *lineAddr)
+		if err != nil {
+			log.Fatal("line Listen: ", err)
+		}
+		log.Printf("line protocol gateway listening on %s", *lineAddr)
+		go func() {
+			err := srv.ServeLine(l)
+			if err != nil {
+				log.Fatal("ServeLine: ", err)
+			}
+		}()
+	}
+
+	if *ircAddr != "" {
+		l, err := net.Listen("tcp", *ircAddr)
+		if err != nil {
+			log.Fatal("irc Listen: ", err)
+		}
+		log.Printf("irc server listening on %s", *ircAddr)
+		go func() {
+			err := srv.ServeIRC(l)
+			if err != nil {
+				log.Fatal("ServeIRC: ", err)
+			}
+		}()
+	}
+
+	if *sshAddr != "" {
+		if *sshAuthorizedKeys == "" {
+			log.Fatal("-ssh-addr requires -ssh-authorized-keys")
+		}
+		config := &webchat.SSHConfig{}
+		config.HostKey, err = webchat.LoadHostKey(*sshHostKey)
+		if err != nil {
+			log.Fatal("LoadHostKey: ", err)
+		}
+		config.AuthorizedKeys, err = webchat.LoadAuthorizedKeys(*sshAuthorizedKeys)
+		if err != nil {
+			log.Fatal("LoadAuthorizedKeys: ", err)
+		}
+		l, err := net.Listen("tcp", *sshAddr)
+		if err != nil {
+			log.Fatal("ssh Listen: ", err)
+		}
+		log.Printf("ssh server listening on %s", *sshAddr)
+		go func() {
+			err := srv.ServeSSH(l, config)
+			if err != nil {
+				log.Fatal("ServeSSH: ", err)
+			}
+		}()
+	}
+
+	if *adminAddr != "" {
+		admin, err := webchat.NewAdmin(srv, *adminToken)
+		if err != nil {
+			log.Fatal("NewAdmin: ", err)
+		}
+		admin.PurgeHistory = handler.purgeHistory
+		admin.Revisions = handler.history.Revisions
+		log.Printf("admin api listening on %s", *adminAddr)
+		go func() {
+			err := http.ListenAndServe(*adminAddr, admin)
+			if err != nil {
+				log.Fatal("admin ListenAndServe: ", err)
+			}
+		}()
+	}
+
+	api := webchat.NewAPI(srv, handler.history)
+
 	mx := http.NewServeMux()
 
 	log.Printf("serving static data from %s", staticDir)
 
 	mx.HandleFunc("/", handler.serveHome)
 	mx.HandleFunc("/ws", srv.ServeWebSocket)
+	mx.HandleFunc("/sse", srv.ServeSSE)
+	mx.HandleFunc("/poll", srv.ServeLongPoll)
+	mx.HandleFunc("/send", srv.ServeSend)
+	mx.Handle("/api/", api)
 	mx.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir))))
 
 	alias := "/chat"
 	mx.HandleFunc(alias, handler.serveHome)
 	mx.HandleFunc(alias+"/ws", srv.ServeWebSocket)
+	mx.HandleFunc(alias+"/sse", srv.ServeSSE)
+	mx.HandleFunc(alias+"/poll", srv.ServeLongPoll)
+	mx.HandleFunc(alias+"/send", srv.ServeSend)
+	mx.Handle(alias+"/api/", http.StripPrefix(alias, api))
 	mx.Handle(alias+"/static/", http.StripPrefix(alias+"/static/", http.FileServer(http.Dir(staticDir))))
 
 	hs := &http.Server{
@@ -147,8 +451,37 @@ func main() {
 		Handler: mx,
 	}
 
-	err = hs.ListenAndServe()
+	if *tlsCert == "" {
+		err = hs.ListenAndServe()
+		if err != nil {
+			log.Fatal("ListenAndServe: ", err)
+		}
+		return
+	}
+
+	reloader, err := webchat.NewCertReloader(*tlsCert, *tlsKey)
+	if err != nil {
+		log.Fatal("NewCertReloader: ", err)
+	}
+	go reloader.Watch(30 * time.Second)
+
+	hs.TLSConfig, err = webchat.NewTLSConfig(reloader, *tlsClientCA)
+	if err != nil {
+		log.Fatal("NewTLSConfig: ", err)
+	}
+
+	if *redirectAddr != "" {
+		_, port, _ := net.SplitHostPort(*addr)
+		go func() {
+			err := http.ListenAndServe(*redirectAddr, webchat.RedirectHTTPS(port))
+			if err != nil {
+				log.Fatal("redirect ListenAndServe: ", err)
+			}
+		}()
+	}
+
+	err = hs.ListenAndServeTLS("", "")
 	if err != nil {
-		log.Fatal("ListenAndServe: ", err)
+		log.Fatal("ListenAndServeTLS: ", err)
 	}
 }
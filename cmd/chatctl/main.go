@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sigmonsays/webchat"
+)
+
+const usage = `usage: chatctl [flags] <command> [args]
+
+commands:
+  connections             list connections
+  kick <id>               disconnect a connection
+  bans                    list bans
+  ban <kind> <value>      ban by ip, name or identity, kicking matches
+  unban <kind> <value>    remove a ban
+  notice [-room r] <text> broadcast a system notice
+  rooms                   list rooms with topic and members
+  purge <room>            purge history for a room, "" for the default room, * for all rooms
+  revisions <room> <id>   show previous versions of an edited or deleted message
+  stats                   dump hub stats
+
+flags:
+`
+
+type client struct {
+	addr  string
+	token string
+	http  *http.Client
+}
+
+func (c *client) do(method, path string, body interface{}, ret interface{}) error {
+	var rd io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.addr, "/")+path, rd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("%s %s: %s: %s", method, path, res.Status, strings.TrimSpace(string(msg)))
+	}
+	if ret == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(ret)
+}
+
+func roomName(room string) string {
+	if room == webchat.DefaultRoom {
+		return "(default)"
+	}
+	return room
+}
+
+func printConnections(connections []*webchat.ConnectionInfo) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tREMOTE\tCONNECTED\tIN\tOUT\tIDENTITY")
+	for _, c := range connections {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%d\t%s\n", c.Id, c.Name, c.RemoteAddr,
+			time.Since(c.Connected).Truncate(time.Second), c.BytesIn, c.BytesOut, c.Identity)
+	}
+	tw.Flush()
+}
+
+func printJson(v interface{}) {
+	data, _ := json.MarshalIndent(v, "", "  ")
+	fmt.Println(string(data))
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	addr := flag.String("addr", "http://localhost:8081", "admin api address")
+	token := flag.String("token", os.Getenv("CHAT_ADMIN_TOKEN"), "admin api bearer token")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := &client{
+		addr:  *addr,
+		token: *token,
+		http:  &http.Client{Timeout: 30 * time.Second},
+	}
+	err := run(c, args[0], args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chatctl: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(c *client, command string, args []string) error {
+	switch command {
+	case "connections", "ls":
+		connections := make([]*webchat.ConnectionInfo, 0)
+		err := c.do("GET", "/admin/connections", nil, &connections)
+		if err != nil {
+			return err
+		}
+		printConnections(connections)
+
+	case "kick":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: kick <id>")
+		}
+		return c.do("POST", "/admin/connections/"+url.PathEscape(args[0])+"/kick", nil, nil)
+
+	case "bans":
+		bans := make([]webchat.Ban, 0)
+		err := c.do("GET", "/admin/bans", nil, &bans)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KIND\tVALUE")
+		for _, b := range bans {
+			fmt.Fprintf(tw, "%s\t%s\n", b.Kind, b.Value)
+		}
+		tw.Flush()
+
+	case "ban":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: ban <ip|name|identity> <value>")
+		}
+		ret := make(map[string]int)
+		err := c.do("POST", "/admin/bans", webchat.Ban{Kind: args[0], Value: args[1]}, &ret)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("banned %s %s, kicked %d\n", args[0], args[1], ret["kicked"])
+
+	case "unban":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: unban <ip|name|identity> <value>")
+		}
+		q := url.Values{"kind": {args[0]}, "value": {args[1]}}
+		return c.do("DELETE", "/admin/bans?"+q.Encode(), nil, nil)
+
+	case "notice":
+		fs := flag.NewFlagSet("notice", flag.ExitOnError)
+		room := fs.String("room", "", "room to send the notice to")
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return fmt.Errorf("usage: notice [-room r] <text>")
+		}
+		m := &webchat.Message{Room: *room, Message: strings.Join(fs.Args(), " ")}
+		return c.do("POST", "/admin/notice", m, nil)
+
+	case "rooms":
+		rooms := make([]*webchat.RoomInfo, 0)
+		err := c.do("GET", "/admin/rooms", nil, &rooms)
+		if err != nil {
+			return err
+		}
+		for _, room := range rooms {
+			fmt.Printf("%s members:%d topic:%q\n", roomName(room.Room), len(room.Members), room.Topic)
+			for _, m := range room.Members {
+				fmt.Printf("    %d %s %s\n", m.Id, m.Name, m.RemoteAddr)
+			}
+		}
+
+	case "purge":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: purge <room>")
+		}
+		room := args[0]
+		ret := make(map[string]int)
+		err := c.do("POST", "/admin/history/purge?room="+url.QueryEscape(room), nil, &ret)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("purged %d messages\n", ret["purged"])
+
+	case "revisions":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: revisions <room> <id>")
+		}
+		q := url.Values{"room": {args[0]}, "id": {args[1]}}
+		revisions := make([]*webchat.Message, 0)
+		err := c.do("GET", "/admin/history/revisions?"+q.Encode(), nil, &revisions)
+		if err != nil {
+			return err
+		}
+		for _, m := range revisions {
+			when := time.Unix(0, m.Time*int64(time.Millisecond))
+			if m.Edited != 0 {
+				when = time.Unix(0, m.Edited*int64(time.Millisecond))
+			}
+			fmt.Printf("%s <%s> %s\n", when.Format(time.RFC3339), m.From, m.Message)
+		}
+
+	case "stats":
+		ret := make(map[string]interface{})
+		err := c.do("GET", "/admin/stats", nil, &ret)
+		if err != nil {
+			return err
+		}
+		printJson(ret)
+
+	default:
+		return fmt.Errorf("unknown command %s, see -help", command)
+	}
+	return nil
+}
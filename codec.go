@@ -0,0 +1,76 @@
+package webchat
+
+import (
+	"encoding/json"
+)
+
+// Codec encodes messages on the wire. Websocket clients pick one by asking
+// for its Name as a subprotocol, JSONCodec is used when they ask for none.
+type Codec interface {
+	// Name is the websocket subprotocol of the codec, ie webchat.json
+	Name() string
+
+	Marshal(m *Message) ([]byte, error)
+	Unmarshal(data []byte, m *Message) error
+
+	// Binary reports whether frames are sent as binary rather than text
+	// websocket messages
+	Binary() bool
+}
+
+// JSONCodec is the default codec, the encoding of Message.Json
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string {
+	return "webchat.json"
+}
+
+func (JSONCodec) Marshal(m *Message) ([]byte, error) {
+	return json.Marshal(m)
+}
+
+func (JSONCodec) Unmarshal(data []byte, m *Message) error {
+	return json.Unmarshal(data, m)
+}
+
+func (JSONCodec) Binary() bool {
+	return false
+}
+
+// MsgpackCodec encodes messages as MessagePack maps with the json field
+// names, a compact binary encoding for bots and mobile clients
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Name() string {
+	return "webchat.msgpack"
+}
+
+func (MsgpackCodec) Marshal(m *Message) ([]byte, error) {
+	return MarshalMsgpack(m)
+}
+
+func (MsgpackCodec) Unmarshal(data []byte, m *Message) error {
+	return UnmarshalMsgpack(data, m)
+}
+
+func (MsgpackCodec) Binary() bool {
+	return true
+}
+
+// RegisterCodec offers c to websocket clients. Codecs registered first are
+// preferred when a client asks for several. It must be called before
+// serving.
+func (h *Handler) RegisterCodec(c Codec) {
+	h.codecs = append(h.codecs, c)
+	h.upgrader.Subprotocols = append(h.upgrader.Subprotocols, c.Name())
+}
+
+// codec returns the codec negotiated as subprotocol, JSONCodec when none was
+func (h *Handler) codec(subprotocol string) Codec {
+	for _, c := range h.codecs {
+		if c.Name() == subprotocol {
+			return c
+		}
+	}
+	return JSONCodec{}
+}
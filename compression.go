@@ -0,0 +1,78 @@
+package webchat
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// defaults of Handler.CompressionLevel and Handler.CompressionThreshold
+	defaultCompressionLevel     = 1
+	defaultCompressionThreshold = 256
+)
+
+// compressionStats counts what compressing websocket transports write, the
+// ratio is the bytes on the wire over the bytes of the messages
+type compressionStats struct {
+	connections int64
+	messages    int64
+	wire        int64
+}
+
+func (s *compressionStats) ratio() float64 {
+	messages := atomic.LoadInt64(&s.messages)
+	if messages == 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&s.wire)) / float64(messages)
+}
+
+// offersDeflate reports whether the client asked for permessage-deflate, it
+// is negotiated when the handler enables compression
+func offersDeflate(r *http.Request) bool {
+	for _, header := range r.Header["Sec-Websocket-Extensions"] {
+		for _, ext := range strings.Split(header, ",") {
+			name := strings.Split(ext, ";")[0]
+			if strings.TrimSpace(name) == "permessage-deflate" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countingConn counts the bytes written to a hijacked connection
+type countingConn struct {
+	net.Conn
+	written int64
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	atomic.AddInt64(&c.written, int64(n))
+	return n, err
+}
+
+// countingWriter hands the websocket upgrade a countingConn when it hijacks
+// the connection
+type countingWriter struct {
+	http.ResponseWriter
+	conn *countingConn
+}
+
+func (w *countingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if ok == false {
+		return nil, nil, fmt.Errorf("response does not implement http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	w.conn = &countingConn{Conn: conn}
+	return w.conn, rw, nil
+}
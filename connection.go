@@ -1,7 +1,6 @@
 package webchat
 
 import (
-	"github.com/gorilla/websocket"
 	"time"
 )
 
@@ -17,73 +16,97 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 512
-)
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-}
+	// Maximum size of a json encoded message from the peer, the text and
+	// its attachments
+	maxFrameSize = 8192
+)
 
-// connection is an middleman between the websocket connection and the hub.
+// connection is an middleman between the transport and the hub.
 type Connection struct {
 	hub *Hub
 	id  int64
 
+	// Name is the nick of the peer. The hub sets it after the callbacks of
+	// a NickOp ran, other goroutines read it under the hub lock.
 	Name string
 
-	// The websocket connection.
-	ws *websocket.Conn
+	// Identity is the verified identity of the peer, ie a client certificate
+	// common name. It is empty for anonymous connections.
+	Identity string
+
+	// Name was verified along with Identity, the peer can not change it and
+	// every message it sends is from Name
+	pinned bool
+
+	// The underlying transport, ie a websocket
+	transport Transport
 
 	// Buffered channel of outbound messages.
 	send chan *Message
+
+	// rooms joined, protected by the hub lock
+	rooms map[string]bool
+
+	// lower case words that highlight a message, the same on every connection
+	// of an identity with Users. protected by the hub lock
+	keywords []string
+
+	// messages are kept until acknowledged, protected by the hub lock
+	acking bool
+
+	// protocol version and features declared with a HelloOp, nil features
+	// when the client did not say hello. protected by the hub lock
+	version  int
+	features map[string]bool
+
+	connected time.Time
+}
+
+// bytes returns the bytes read from and written to the peer, zero when the
+// transport does not count them
+func (c *Connection) bytes() (in, out int64) {
+	counter, ok := c.transport.(byteCounter)
+	if ok == false {
+		return 0, 0
+	}
+	return counter.counted()
 }
 
-// readPump pumps messages from the websocket connection to the hub.
+// readPump pumps messages from the transport to the hub.
 func (c *Connection) readPump() {
 	defer func() {
 		c.hub.unregister <- c
-		c.ws.Close()
+		c.transport.Close()
 	}()
-	c.ws.SetReadLimit(maxMessageSize)
-	c.ws.SetReadDeadline(time.Now().Add(pongWait))
-	c.ws.SetPongHandler(func(string) error { c.ws.SetReadDeadline(time.Now().Add(pongWait)); return nil })
 	for {
-		_, msg, err := c.ws.ReadMessage()
+		m, err := c.transport.ReadMessage()
 		if err != nil {
 			break
 		}
-		c.hub.broadcast <- &message{
-			connection: c,
-			data:       msg,
-		}
+		m.connection = c
+		c.hub.broadcast <- m
 	}
 }
 
-// write writes a message with the given message type and payload.
-func (c *Connection) write(mt int, payload []byte) error {
-	c.ws.SetWriteDeadline(time.Now().Add(writeWait))
-	return c.ws.WriteMessage(mt, payload)
-}
-
-// writePump pumps messages from the hub to the websocket connection.
+// writePump pumps messages from the hub to the transport.
 func (c *Connection) writePump() {
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
 		ticker.Stop()
-		c.ws.Close()
+		c.transport.Close()
 	}()
 	for {
 		select {
 		case message, ok := <-c.send:
 			if !ok {
-				c.write(websocket.CloseMessage, []byte{})
 				return
 			}
-			if err := c.write(websocket.TextMessage, message.Json()); err != nil {
+			if err := c.transport.WriteMessage(message); err != nil {
 				return
 			}
 		case <-ticker.C:
-			if err := c.write(websocket.PingMessage, []byte{}); err != nil {
+			if err := c.transport.Ping(); err != nil {
 				return
 			}
 		}
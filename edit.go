@@ -0,0 +1,58 @@
+package webchat
+
+import (
+	"fmt"
+)
+
+// authorOf returns the Author of messages sent by c. Verified identities
+// survive reconnects, anonymous connections only own their own messages.
+func authorOf(c *Connection) string {
+	if c.Identity != "" {
+		return IdentityAuthor(c.Identity)
+	}
+	return fmt.Sprintf("conn:%d", c.id)
+}
+
+// IdentityAuthor returns the Author for messages posted on behalf of a
+// verified identity
+func IdentityAuthor(identity string) string {
+	return "identity:" + identity
+}
+
+// AuthorConnections returns the connections whose messages have author
+func (h *Hub) AuthorConnections(author string) []*Connection {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ret := make([]*Connection, 0)
+	for c := range h.connections {
+		if authorOf(c) == author {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// SetModerators sets the identities that may edit and delete any message
+func (h *Hub) SetModerators(identities []string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.moderators = make(map[string]bool)
+	for _, identity := range identities {
+		h.moderators[identity] = true
+	}
+}
+
+// IsModerator reports whether c has a moderator identity
+func (h *Hub) IsModerator(c *Connection) bool {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return c.Identity != "" && h.moderators[c.Identity]
+}
+
+// MayModify reports whether c may edit or delete original
+func (h *Hub) MayModify(c *Connection, original *Message) bool {
+	if original.Author != "" && original.Author == authorOf(c) {
+		return true
+	}
+	return h.IsModerator(c)
+}
@@ -5,12 +5,25 @@ import (
 	"net/http"
 	"sync"
 	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 func NewHandler(hub *Hub) (*Handler, error) {
 	h := &Handler{
-		hub: hub,
+		hub:                  hub,
+		sessions:             make(map[string]sessionTransport),
+		CompressionLevel:     defaultCompressionLevel,
+		CompressionThreshold: defaultCompressionThreshold,
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
 	}
+	h.RegisterCodec(JSONCodec{})
+	h.RegisterCodec(MsgpackCodec{})
 	return h, nil
 
 }
@@ -19,21 +32,105 @@ type Handler struct {
 	mx          sync.Mutex
 	connections int64
 	hub         *Hub
+	upgrader    websocket.Upgrader
+
+	// AllowedOrigins lists additional origins permitted to open a websocket.
+	// see MatchOrigin for the pattern syntax
+	AllowedOrigins []string
+
+	// CheckOrigin replaces the built in origin checks when set
+	CheckOrigin CheckOriginFn
+
+	rejectedOrigins int64
+
+	// sessions for the sse and long poll transports, by session id
+	sessions map[string]sessionTransport
+
+	// codecs websocket clients may ask for, see RegisterCodec
+	codecs []Codec
+
+	// EnableCompression negotiates permessage-deflate with websocket clients
+	// that offer it. Messages are compressed at CompressionLevel, -2 to 9
+	// as in compress/flate, unless they are shorter than
+	// CompressionThreshold bytes. They must be set before serving.
+	EnableCompression    bool
+	CompressionLevel     int
+	CompressionThreshold int
+
+	compression compressionStats
+}
+
+// HandlerStats is a snapshot of handler counters
+type HandlerStats struct {
+	Connections     int64 `json:"connections"`
+	RejectedOrigins int64 `json:"rejected_origins"`
+
+	// websocket connections that negotiated compression, the bytes of the
+	// messages written to them and the bytes that went on the wire
+	CompressedConnections  int64   `json:"compressed_connections"`
+	CompressedMessageBytes int64   `json:"compressed_message_bytes"`
+	CompressedWireBytes    int64   `json:"compressed_wire_bytes"`
+	CompressionRatio       float64 `json:"compression_ratio"`
+}
+
+func (h *Handler) Stats() *HandlerStats {
+	return &HandlerStats{
+		Connections:            atomic.LoadInt64(&h.connections),
+		RejectedOrigins:        atomic.LoadInt64(&h.rejectedOrigins),
+		CompressedConnections:  atomic.LoadInt64(&h.compression.connections),
+		CompressedMessageBytes: atomic.LoadInt64(&h.compression.messages),
+		CompressedWireBytes:    atomic.LoadInt64(&h.compression.wire),
+		CompressionRatio:       h.compression.ratio(),
+	}
 }
 
 // serveWs handles websocket requests from the peer.
 func (h *Handler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	upgrader := h.upgrader
+	upgrader.EnableCompression = h.EnableCompression
+	// count what goes on the wire of compressed connections for the
+	// compression ratio
+	var cw *countingWriter
+	if h.EnableCompression && offersDeflate(r) {
+		cw = &countingWriter{ResponseWriter: w}
+		w = cw
+	}
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err)
 		return
 	}
+	t := newWsTransport(ws, h.codec(ws.Subprotocol()))
+	if cw != nil {
+		err = ws.SetCompressionLevel(h.CompressionLevel)
+		if err != nil {
+			log.Printf("compression level %d: %s", h.CompressionLevel, err)
+		}
+		t.compress(h.CompressionThreshold, cw.conn, &h.compression)
+	}
+	identity := clientIdentity(r)
+	h.ServeTransport(t, identity, identity)
+}
+
+// ServeTransport registers a connection over t with the hub and pumps
+// messages until the transport is closed. identity is the verified identity
+// of the peer, if any, and name its initial display name. A name given with
+// an identity is verified too and can not be changed by the peer.
+func (h *Handler) ServeTransport(t Transport, identity, name string) {
 	id := atomic.AddInt64(&h.connections, 1)
 	c := &Connection{
-		id:   id,
-		hub:  h.hub,
-		send: make(chan *Message, 256),
-		ws:   ws,
+		id:        id,
+		hub:       h.hub,
+		send:      make(chan *Message, 256),
+		transport: t,
+		Identity:  identity,
+		rooms:     map[string]bool{DefaultRoom: true},
+		connected: time.Now(),
+	}
+	c.Name = name
+	c.pinned = identity != "" && name != ""
+	if a, ok := t.(connectionAware); ok {
+		a.attach(c)
 	}
 	h.hub.register <- c
 	go c.writePump()
@@ -0,0 +1,223 @@
+package webchat
+
+import (
+	"fmt"
+	"sync"
+)
+
+// History stores messages for replay and the rest api
+type History interface {
+	// Add stores m, which must have a MessageId
+	Add(m *Message) error
+
+	// Messages returns up to limit messages in room, oldest first. When before
+	// is not zero only messages with a lower MessageId are returned. Deleted
+	// messages are skipped.
+	Messages(room string, before int64, limit int) ([]*Message, error)
+
+	// Get returns the message with id in room, including deleted messages
+	Get(room string, id int64) (*Message, error)
+
+	// Update replaces the stored message with the same room and MessageId,
+	// keeping the previous version as a revision
+	Update(m *Message) error
+
+	// Thread returns the root message id and its replies, oldest first.
+	// Adding a reply updates Replies and LastReply of the root.
+	Thread(room string, root int64) ([]*Message, error)
+
+	// React adds or removes the reaction of author, shown as name, to a
+	// message. A user is the same across nick changes. It returns the
+	// reactions of the message and whether they changed.
+	React(room string, id int64, emoji, author, name string, remove bool) (map[string][]string, bool, error)
+
+	// SetPreview sets the link preview of a message
+	SetPreview(room string, id int64, p *Preview) error
+
+	// Revisions returns the previous versions of a message, oldest first
+	Revisions(room string, id int64) ([]*Message, error)
+
+	// Purge removes messages in room, or every room when room is "*". It
+	// returns the number of messages removed.
+	Purge(room string) (int, error)
+}
+
+// MemoryHistory keeps the latest messages of each room in memory
+type MemoryHistory struct {
+	mx      sync.Mutex
+	perRoom int
+	rooms   map[string][]*Message
+
+	// previous versions by MessageId
+	revisions map[int64][]*Message
+}
+
+func NewMemoryHistory(perRoom int) *MemoryHistory {
+	return &MemoryHistory{
+		perRoom:   perRoom,
+		rooms:     make(map[string][]*Message),
+		revisions: make(map[int64][]*Message),
+	}
+}
+
+func (h *MemoryHistory) Add(m *Message) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if m.ReplyTo != 0 {
+		if i, err := h.find(m.Room, m.ReplyTo); err == nil {
+			// replace rather than modify, the root may be in flight
+			root := *h.rooms[m.Room][i]
+			root.Replies++
+			root.LastReply = m.Time
+			h.rooms[m.Room][i] = &root
+		}
+	}
+	// a copy, the history outlives the connection of the sender
+	stored := *m
+	stored.connection = nil
+	ls := append(h.rooms[m.Room], &stored)
+	if len(ls) > h.perRoom {
+		for _, old := range ls[:len(ls)-h.perRoom] {
+			delete(h.revisions, old.MessageId)
+		}
+		ls = ls[len(ls)-h.perRoom:]
+	}
+	h.rooms[m.Room] = ls
+	return nil
+}
+
+func (h *MemoryHistory) Messages(room string, before int64, limit int) ([]*Message, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ls := h.rooms[room]
+	end := len(ls)
+	if before != 0 {
+		for end > 0 && ls[end-1].MessageId >= before {
+			end--
+		}
+	}
+	ret := make([]*Message, 0)
+	for i := end - 1; i >= 0 && len(ret) < limit; i-- {
+		if ls[i].Deleted == false {
+			ret = append(ret, ls[i])
+		}
+	}
+	for i, j := 0, len(ret)-1; i < j; i, j = i+1, j-1 {
+		ret[i], ret[j] = ret[j], ret[i]
+	}
+	return ret, nil
+}
+
+// find returns the index of id in room, the lock must be held
+func (h *MemoryHistory) find(room string, id int64) (int, error) {
+	for i, m := range h.rooms[room] {
+		if m.MessageId == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("message %d not found", id)
+}
+
+func (h *MemoryHistory) Get(room string, id int64) (*Message, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	i, err := h.find(room, id)
+	if err != nil {
+		return nil, err
+	}
+	return h.rooms[room][i], nil
+}
+
+func (h *MemoryHistory) Update(m *Message) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	i, err := h.find(m.Room, m.MessageId)
+	if err != nil {
+		return err
+	}
+	h.revisions[m.MessageId] = append(h.revisions[m.MessageId], h.rooms[m.Room][i])
+	stored := *m
+	stored.connection = nil
+	h.rooms[m.Room][i] = &stored
+	return nil
+}
+
+func (h *MemoryHistory) Thread(room string, root int64) ([]*Message, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	i, err := h.find(room, root)
+	if err != nil {
+		return nil, err
+	}
+	ls := h.rooms[room]
+	ret := []*Message{ls[i]}
+	for _, m := range ls[i+1:] {
+		if m.ReplyTo == root && m.Deleted == false {
+			ret = append(ret, m)
+		}
+	}
+	return ret, nil
+}
+
+func (h *MemoryHistory) React(room string, id int64, emoji, author, name string, remove bool) (map[string][]string, bool, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	i, err := h.find(room, id)
+	if err != nil {
+		return nil, false, err
+	}
+	m := *h.rooms[room][i]
+	if m.Deleted {
+		return nil, false, fmt.Errorf("message %d was deleted", id)
+	}
+	changed, err := applyReaction(&m, emoji, author, name, remove)
+	if err != nil || changed == false {
+		return m.Reactions, false, err
+	}
+	h.rooms[room][i] = &m
+	return m.Reactions, true, nil
+}
+
+func (h *MemoryHistory) SetPreview(room string, id int64, p *Preview) error {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	i, err := h.find(room, id)
+	if err != nil {
+		return err
+	}
+	m := *h.rooms[room][i]
+	if m.Deleted {
+		return fmt.Errorf("message %d was deleted", id)
+	}
+	m.Preview = p
+	h.rooms[room][i] = &m
+	return nil
+}
+
+func (h *MemoryHistory) Revisions(room string, id int64) ([]*Message, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	_, err := h.find(room, id)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*Message, len(h.revisions[id]))
+	copy(ret, h.revisions[id])
+	return ret, nil
+}
+
+func (h *MemoryHistory) Purge(room string) (int, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	purged := 0
+	for name, ls := range h.rooms {
+		if room == "*" || name == room {
+			purged += len(ls)
+			for _, m := range ls {
+				delete(h.revisions, m.MessageId)
+			}
+			delete(h.rooms, name)
+		}
+	}
+	return purged, nil
+}
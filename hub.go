@@ -4,40 +4,69 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// a low level message
-type message struct {
-	data       []byte
-	connection *Connection
-}
-
-func (m *message) String() string {
-	return fmt.Sprintf("<conn:%d data:%d>", m.connection.id, len(m.data))
-}
-
 type CallbackFn func(op OpCode, hub *Hub, c *Connection, m *Message) error
 
 // hub maintains the set of active connections and broadcasts messages to the
 // connections.
 type Hub struct {
 	connections map[*Connection]bool
-	broadcast   chan *message
+	broadcast   chan *Message
 	register    chan *Connection
 	unregister  chan *Connection
 	mx          sync.Mutex
 
 	callbacks map[OpCode][]CallbackFn
+
+	// topic by room
+	topics map[string]string
+
+	bans map[Ban]bool
+
+	// identities that may edit and delete any message
+	moderators map[string]bool
+
+	// index messages are added to, nil when search is disabled
+	index *SearchIndex
+
+	// offline queues and read markers, nil when disabled
+	users *Users
+
+	// uploaded files messages may attach, nil when disabled
+	uploads *Uploads
+
+	// fetches link previews, nil when disabled
+	previewer *Previewer
+
+	// receipts of messages sent with WantReceipts, by MessageId, and their
+	// ids oldest first. protected by mx
+	receipts     map[int64]*receipt
+	receiptOrder []int64
+
+	// last assigned MessageId
+	lastId int64
+
+	// counters, see Stats
+	received int64
+	sent     int64
+	dropped  int64
 }
 
 func NewHub() *Hub {
 	callbacks := make(map[OpCode][]CallbackFn, 0)
 	h := &Hub{
-		broadcast:   make(chan *message, 50),
+		broadcast:   make(chan *Message, 50),
 		register:    make(chan *Connection),
 		unregister:  make(chan *Connection),
 		connections: make(map[*Connection]bool),
 		callbacks:   callbacks,
+		topics:      make(map[string]string),
+		bans:        make(map[Ban]bool),
+		moderators:  make(map[string]bool),
+		receipts:    make(map[int64]*receipt),
 	}
 	return h
 }
@@ -52,12 +81,12 @@ func (h *Hub) OnCallback(callback OpCode, fn CallbackFn) {
 }
 
 func (h *Hub) SendMessage(c *Connection, m *Message) error {
-	select {
-	case c.send <- m:
-	default:
-		close(c.send)
-		delete(h.connections, c)
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if _, ok := h.connections[c]; ok == false {
+		return fmt.Errorf("connection %d is gone", c.id)
 	}
+	h.queue(c, m)
 	return nil
 }
 
@@ -66,15 +95,95 @@ func (h *Hub) Send(op OpCode, msg string) {
 	h.SendBroadcast(m)
 }
 
+// SendBroadcast sends m to every connection in m.Room. Recipients mentioned
+// in a MessageOp get a copy with Notify set. A MessageOp is accepted once it
+// is broadcast, it is then indexed for search and its links are previewed.
 func (h *Hub) SendBroadcast(m *Message) {
+	h.stamp(m)
+	var ms *mentions
+	if m.Op == MessageOp {
+		ms = parseMentions(m.Message)
+		if h.index != nil {
+			h.index.Add(m)
+		}
+		if h.previewer != nil {
+			h.preview(m)
+		}
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if m.Op == MessageOp && m.WantReceipts && m.Author != "" {
+		h.trackReceipts(m)
+	}
+	if m.Op == MessageOp && h.users != nil {
+		// before it is queued, an ack may follow right away
+		h.users.pend(m)
+	}
+	online := make(map[string]bool)
 	for c := range h.connections {
-		m.Id = c.id
-		select {
-		case c.send <- m:
-		default:
-			close(c.send)
-			delete(h.connections, c)
+		if c.Identity != "" {
+			online[c.Identity] = true
 		}
+		if c.rooms[m.Room] == false {
+			continue
+		}
+		if ms != nil && c != m.connection && ms.notify(c) {
+			notify := *m
+			notify.Notify = true
+			h.queue(c, &notify)
+			continue
+		}
+		h.queue(c, m)
+	}
+	if ms != nil && h.users != nil {
+		h.users.offer(m, ms, online)
+	}
+}
+
+// stamp assigns a MessageId and Time to messages that have none
+func (h *Hub) stamp(m *Message) {
+	if m.MessageId == 0 {
+		m.MessageId = atomic.AddInt64(&h.lastId, 1)
+	}
+	if m.Time == 0 {
+		m.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+}
+
+// content resolves the attachments and renders the text of messages and
+// edits
+func (h *Hub) content(m *Message) {
+	if m.Op == MessageOp {
+		m.Attachments = h.uploads.resolve(m.Author, m.Attachments)
+	} else {
+		m.Attachments = nil
+	}
+	if m.Op == MessageOp || m.Op == EditOp {
+		m.Html = RenderMarkdown(m.Message)
+	}
+}
+
+// Post dispatches a message that did not arrive over a connection, ie from
+// the rest api. Callbacks receive a nil connection.
+func (h *Hub) Post(m *Message) {
+	m.connection = nil
+	h.broadcast <- m
+}
+
+// queue queues m for c, dropping c if it can not keep up. Ops of features c
+// did not declare are skipped. The hub lock must be held.
+func (h *Hub) queue(c *Connection, m *Message) {
+	if c.supports(m.Op) == false {
+		return
+	}
+	select {
+	case c.send <- m:
+		atomic.AddInt64(&h.sent, 1)
+	default:
+		log.Printf("dropping slow connection id:%d remote:%s\n", c.id, c.transport.RemoteAddr())
+		atomic.AddInt64(&h.dropped, 1)
+		close(c.send)
+		delete(h.connections, c)
 	}
 }
 
@@ -109,35 +218,159 @@ func (h *Hub) Start() {
 	for {
 		select {
 		case c := <-h.register:
-			log.Printf("register connection id:%d remote:%s\n", c.id, c.ws.RemoteAddr())
+			log.Printf("register connection id:%d remote:%s identity:%s\n", c.id, c.transport.RemoteAddr(), c.Identity)
+			if h.banned(c, "") {
+				log.Printf("rejecting banned connection id:%d remote:%s\n", c.id, c.transport.RemoteAddr())
+				close(c.send)
+				continue
+			}
+			h.mx.Lock()
 			h.connections[c] = true
+			if h.users != nil && c.Identity != "" {
+				c.keywords = h.users.Keywords(c.Identity)
+			}
+			h.mx.Unlock()
 
+			if h.users != nil && c.Identity != "" {
+				h.users.Joined(c.Identity, DefaultRoom, true, atomic.LoadInt64(&h.lastId))
+			}
+
+			h.sendHello(c)
 			h.dispatch(RegisterOp, c, nil)
+			h.deliverOffline(c)
 
 		case c := <-h.unregister:
-			log.Printf("unregister connection id:%d remote:%s\n", c.id, c.ws.RemoteAddr())
-			if _, ok := h.connections[c]; ok {
+			// the read pump queues its last messages before it unregisters,
+			// handle them while the connection is still registered
+			for n := len(h.broadcast); n > 0; n-- {
+				h.receive(<-h.broadcast)
+			}
+			log.Printf("unregister connection id:%d remote:%s\n", c.id, c.transport.RemoteAddr())
+			h.mx.Lock()
+			_, ok := h.connections[c]
+			if ok {
 				delete(h.connections, c)
 				close(c.send)
+			}
+			h.mx.Unlock()
+			if ok {
 				h.dispatch(UnregisterOp, c, nil)
 			}
 
-		case data := <-h.broadcast:
-			m := &Message{
-				connection: data.connection,
-				Id:         data.connection.id,
-			}
+		case m := <-h.broadcast:
+			h.receive(m)
+		}
+	}
+}
 
-			err := m.FromJson(data.data)
-			if err != nil {
-				log.Printf("ERROR: FromJson [ %s ]: %s", data, err)
-				continue
-			}
-			log.Printf("dispatch %s %s %s\n", m.Op, data, string(data.data))
-			err = h.dispatch(m.Op, data.connection, m)
-			if err != nil {
-				log.Printf("dispatch %s: %s\n", m.Op, err)
-			}
+// receive handles a message from a connection or Post
+func (h *Hub) receive(m *Message) {
+	atomic.AddInt64(&h.received, 1)
+	m.Time = 0
+	if m.Op == EditOp || m.Op == DeleteOp || m.Op == ReactionOp || m.Op == ReadOp || m.Op == AckOp || m.Op == PreviewOp {
+		// these address an existing message, or none when the id is 0
+		m.Time = time.Now().UnixNano() / int64(time.Millisecond)
+	} else {
+		m.MessageId = 0
+		h.stamp(m)
+	}
+	if m.connection == nil {
+		h.content(m)
+		log.Printf("dispatch %s <posted> %s\n", m.Op, m.Json())
+		h.dispatch(m.Op, nil, m)
+		return
+	}
+	h.mx.Lock()
+	_, ok := h.connections[m.connection]
+	h.mx.Unlock()
+	if ok == false {
+		// kicked or dropped, the read pump has not noticed yet
+		return
+	}
+	m.Id = m.connection.id
+	m.Author = authorOf(m.connection)
+	m.serverFields()
+	if err := m.checkNames(); err != nil {
+		log.Printf("dropping message with an invalid name id:%d: %s\n", m.Id, err)
+		h.SendMessage(m.connection, &Message{Op: NoticeOp, Message: fmt.Sprintf("invalid message: %s", err)})
+		return
+	}
+	if len(m.Message) > maxMessageSize {
+		log.Printf("dropping oversized message id:%d size:%d\n", m.Id, len(m.Message))
+		return
+	}
+	if err := m.validate(); err != nil {
+		log.Printf("dropping invalid message id:%d: %s\n", m.Id, err)
+		h.SendMessage(m.connection, &Message{Op: NoticeOp, Message: fmt.Sprintf("invalid message: %s", err)})
+		return
+	}
+	if m.connection.pinned {
+		if m.Op == NickOp && m.From != m.connection.Name {
+			h.SendMessage(m.connection, &Message{Op: NoticeOp, Message: fmt.Sprintf("your name is verified as %s and can not be changed", m.connection.Name)})
+			return
 		}
+		m.From = m.connection.Name
 	}
+	h.content(m)
+	if m.Op == NickOp && h.banned(m.connection, m.From) {
+		log.Printf("kicking banned name %s id:%d\n", m.From, m.Id)
+		h.Kick(m.Id)
+		return
+	}
+	if h.users != nil && m.connection.Identity != "" {
+		switch m.Op {
+		case NickOp:
+			h.users.Seen(m.connection.Identity, m.From)
+		case JoinOp, PartOp:
+			h.users.Joined(m.connection.Identity, m.Room, m.Op == JoinOp, atomic.LoadInt64(&h.lastId))
+		}
+	}
+	switch m.Op {
+	case JoinOp:
+		h.Join(m.connection, m.Room)
+	case PartOp:
+		h.Part(m.connection, m.Room)
+	case TopicOp:
+		h.SetTopic(m.Room, m.Message)
+	case SearchOp:
+		h.search(m.connection, m)
+		return
+	case KeywordsOp:
+		h.setKeywords(m.connection, m.Message)
+		return
+	case ReadOp:
+		if h.users != nil && m.connection.Identity != "" {
+			h.users.MarkRead(m.connection.Identity, m.Room, m.MessageId)
+			h.sendUnread(m.connection)
+		}
+		return
+	case UnreadOp:
+		h.sendUnread(m.connection)
+		return
+	case AckOp:
+		h.ack(m.connection, m)
+		return
+	case PreviewOp:
+		// only the server previews links
+		return
+	case HelloOp:
+		h.hello(m.connection, m)
+		return
+	}
+	log.Printf("dispatch %s <conn:%d> %s\n", m.Op, m.Id, m.Json())
+	err := h.dispatch(m.Op, m.connection, m)
+	if err != nil {
+		log.Printf("dispatch %s: %s\n", m.Op, err)
+	}
+	if m.Op == NickOp {
+		// callbacks see the previous name on the connection
+		h.setName(m.connection, m.From)
+	}
+}
+
+// setName sets the name of c, other goroutines read it under the lock
+func (h *Hub) setName(c *Connection, name string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	c.Name = name
 }
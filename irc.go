@@ -0,0 +1,544 @@
+package webchat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	ircServerName = "webchat"
+
+	// irc channel for DefaultRoom, other rooms map to #room
+	ircDefaultChannel = "#chat"
+
+	// irc limits lines to 512 bytes including the line ending
+	ircMaxLine = 512
+)
+
+// ircLine is a parsed irc protocol line
+type ircLine struct {
+	Command string
+	Params  []string
+}
+
+func parseIRCLine(s string) *ircLine {
+	s = strings.TrimRight(s, "\r\n")
+	if strings.HasPrefix(s, ":") {
+		// clients may send a prefix, it is ignored
+		i := strings.Index(s, " ")
+		if i < 0 {
+			return nil
+		}
+		s = s[i+1:]
+	}
+	l := &ircLine{}
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, ":") {
+			l.Params = append(l.Params, s[1:])
+			break
+		}
+		i := strings.Index(s, " ")
+		if i < 0 {
+			i = len(s)
+		}
+		if l.Command == "" {
+			l.Command = strings.ToUpper(s[:i])
+		} else if s[:i] != "" {
+			l.Params = append(l.Params, s[:i])
+		}
+		s = s[i:]
+	}
+	if l.Command == "" {
+		return nil
+	}
+	return l
+}
+
+func (l *ircLine) param(i int) string {
+	if i < len(l.Params) {
+		return l.Params[i]
+	}
+	return ""
+}
+
+func roomToChannel(room string) string {
+	if room == DefaultRoom {
+		return ircDefaultChannel
+	}
+	return "#" + room
+}
+
+// channelToRoom returns the room of a channel. Channel names are case
+// insensitive, the room is the lower case name unless a room that matches
+// exists.
+func channelToRoom(channel string, rooms []string) string {
+	channel = ircLower(channel)
+	if channel == ircDefaultChannel {
+		return DefaultRoom
+	}
+	for _, room := range rooms {
+		if ircLower(roomToChannel(room)) == channel {
+			return room
+		}
+	}
+	return strings.TrimPrefix(channel, "#")
+}
+
+// ircLower folds s with the rfc 1459 casemapping, {}|^ are the lower case
+// of []\~
+func ircLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '[':
+			return '{'
+		case ']':
+			return '}'
+		case '\\':
+			return '|'
+		case '~':
+			return '^'
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// ircNick makes a webchat name usable as an irc nick
+func ircNick(name string) string {
+	if name == "" {
+		return "*"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', ',', '!', '@', ':', '\r', '\n', '\x00':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// ircTransport implements a minimal irc server for a single client
+type ircTransport struct {
+	frameBytes
+
+	conn    net.Conn
+	scanner *bufio.Scanner
+	c       *Connection
+	pending []*Message
+
+	mx         sync.Mutex
+	nick       string
+	user       bool
+	registered bool
+}
+
+func newIRCTransport(conn net.Conn) *ircTransport {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, ircMaxLine), ircMaxLine)
+	return &ircTransport{
+		conn:    conn,
+		scanner: scanner,
+	}
+}
+
+func (t *ircTransport) attach(c *Connection) {
+	t.c = c
+}
+
+// channelRoom returns the room of channel
+func (t *ircTransport) channelRoom(channel string) string {
+	return channelToRoom(channel, t.c.hub.Rooms())
+}
+
+func (t *ircTransport) getNick() string {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.nick
+}
+
+func (t *ircTransport) isRegistered() bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.registered
+}
+
+// writeLine writes a single line, line breaks and nul bytes in the arguments
+// are replaced so they can not end the line early and start a command
+func (t *ircTransport) writeLine(format string, args ...interface{}) error {
+	line := strings.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' || r == '\x00' {
+			return ' '
+		}
+		return r
+	}, fmt.Sprintf(format, args...))
+	if len(line) > ircMaxLine-2 {
+		// drop a rune cut in half
+		line = strings.ToValidUTF8(line[:ircMaxLine-2], "")
+	}
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	n, err := io.WriteString(t.conn, line+"\r\n")
+	t.wrote(n)
+	return err
+}
+
+// reply sends a numeric reply to the client
+func (t *ircTransport) reply(code string, params string) error {
+	return t.writeLine(":%s %s %s %s", ircServerName, code, ircNick(t.getNick()), params)
+}
+
+func (t *ircTransport) prefix(nick string) string {
+	nick = ircNick(nick)
+	return fmt.Sprintf("%s!%s@%s", nick, nick, ircServerName)
+}
+
+func (t *ircTransport) ReadMessage() (*Message, error) {
+	for {
+		if len(t.pending) > 0 {
+			m := t.pending[0]
+			t.pending = t.pending[1:]
+			return m, nil
+		}
+
+		t.conn.SetReadDeadline(time.Now().Add(pongWait))
+		if t.scanner.Scan() == false {
+			err := t.scanner.Err()
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		t.read(len(t.scanner.Bytes()) + 1)
+		l := parseIRCLine(t.scanner.Text())
+		if l == nil {
+			continue
+		}
+		err := t.handle(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// handle processes a single client command, messages for the hub are queued
+// on pending
+func (t *ircTransport) handle(l *ircLine) error {
+	switch l.Command {
+	case "PING":
+		t.writeLine(":%s PONG %s :%s", ircServerName, ircServerName, l.param(0))
+		return nil
+	case "PONG", "PASS":
+		return nil
+	case "CAP":
+		if l.param(0) == "LS" || l.param(0) == "LIST" {
+			t.writeLine(":%s CAP * %s :", ircServerName, l.param(0))
+		}
+		return nil
+	case "QUIT":
+		t.writeLine("ERROR :Closing link")
+		return io.EOF
+	case "NICK":
+		return t.handleNick(l)
+	case "USER":
+		t.mx.Lock()
+		t.user = true
+		t.mx.Unlock()
+		t.checkRegistered()
+		return nil
+	}
+
+	if t.isRegistered() == false {
+		t.reply("451", ":You have not registered")
+		return nil
+	}
+
+	switch l.Command {
+	case "JOIN":
+		if l.param(0) == "0" {
+			for _, room := range t.c.hub.Rooms() {
+				if t.c.hub.InRoom(t.c, room) {
+					t.part(room)
+				}
+			}
+			return nil
+		}
+		for _, channel := range strings.Split(l.param(0), ",") {
+			if strings.HasPrefix(channel, "#") == false || len(channel) < 2 || checkName("room", channel[1:]) != nil {
+				t.reply("403", channel+" :No such channel")
+				continue
+			}
+			t.join(t.channelRoom(channel))
+		}
+	case "PART":
+		for _, channel := range strings.Split(l.param(0), ",") {
+			room := t.channelRoom(channel)
+			if t.c.hub.InRoom(t.c, room) == false {
+				t.reply("442", channel+" :You're not on that channel")
+				continue
+			}
+			t.part(room)
+		}
+	case "PRIVMSG", "NOTICE":
+		t.handlePrivmsg(l)
+	case "NAMES":
+		for _, channel := range strings.Split(l.param(0), ",") {
+			t.names(t.channelRoom(channel))
+		}
+	case "TOPIC":
+		channel := l.param(0)
+		room := t.channelRoom(channel)
+		if len(l.Params) < 2 {
+			t.topic(room)
+			return nil
+		}
+		if t.c.hub.InRoom(t.c, room) == false {
+			t.reply("442", channel+" :You're not on that channel")
+			return nil
+		}
+		t.pending = append(t.pending, &Message{Op: TopicOp, Room: room, From: t.getNick(), Message: l.param(1)})
+	case "MODE":
+		if strings.HasPrefix(l.param(0), "#") {
+			t.reply("324", l.param(0)+" +nt")
+		} else {
+			t.reply("221", "+i")
+		}
+	case "WHO":
+		t.reply("315", l.param(0)+" :End of WHO list")
+	default:
+		t.reply("421", l.Command+" :Unknown command")
+	}
+	return nil
+}
+
+func (t *ircTransport) handleNick(l *ircLine) error {
+	nick := l.param(0)
+	if nick == "" {
+		t.reply("431", ":No nickname given")
+		return nil
+	}
+	if nick != ircNick(nick) || strings.HasPrefix(nick, "#") || checkName("name", nick) != nil {
+		t.reply("432", nick+" :Erroneous nickname")
+		return nil
+	}
+	t.mx.Lock()
+	old := t.nick
+	registered := t.registered
+	t.nick = nick
+	t.mx.Unlock()
+
+	if registered == false {
+		t.checkRegistered()
+		return nil
+	}
+	t.writeLine(":%s NICK :%s", t.prefix(old), nick)
+	t.pending = append(t.pending, &Message{Op: NickOp, From: nick})
+	return nil
+}
+
+// checkRegistered completes registration once NICK and USER were given. The
+// client is placed in the default channel like every other connection.
+func (t *ircTransport) checkRegistered() {
+	t.mx.Lock()
+	if t.registered || t.nick == "" || t.user == false {
+		t.mx.Unlock()
+		return
+	}
+	t.registered = true
+	nick := t.nick
+	t.mx.Unlock()
+
+	t.reply("001", ":Welcome to webchat "+t.prefix(nick))
+	t.reply("002", ":Your host is "+ircServerName)
+	t.reply("003", ":This server speaks a minimal irc dialect")
+	t.reply("004", ircServerName+" webchat i nt")
+	t.reply("422", ":MOTD File is missing")
+
+	t.pending = append(t.pending, &Message{Op: NickOp, From: nick})
+	t.join(DefaultRoom)
+}
+
+func (t *ircTransport) join(room string) {
+	channel := roomToChannel(room)
+	t.c.hub.Join(t.c, room)
+	t.writeLine(":%s JOIN %s", t.prefix(t.getNick()), channel)
+	t.topic(room)
+	t.names(room)
+	t.pending = append(t.pending, &Message{Op: JoinOp, Room: room, From: t.getNick()})
+}
+
+func (t *ircTransport) part(room string) {
+	t.c.hub.Part(t.c, room)
+	t.writeLine(":%s PART %s", t.prefix(t.getNick()), roomToChannel(room))
+	t.pending = append(t.pending, &Message{Op: PartOp, Room: room, From: t.getNick()})
+}
+
+func (t *ircTransport) topic(room string) {
+	channel := roomToChannel(room)
+	topic := t.c.hub.Topic(room)
+	if topic == "" {
+		t.reply("331", channel+" :No topic is set")
+		return
+	}
+	t.reply("332", channel+" :"+topic)
+}
+
+func (t *ircTransport) names(room string) {
+	channel := roomToChannel(room)
+	names := make([]string, 0)
+	for c, name := range t.c.hub.Names(room) {
+		if c == t.c {
+			// the hub may not have seen our nick yet
+			name = t.getNick()
+		}
+		if name != "" {
+			names = append(names, ircNick(name))
+		}
+	}
+	sort.Strings(names)
+	t.reply("353", "= "+channel+" :"+strings.Join(names, " "))
+	t.reply("366", channel+" :End of NAMES list")
+}
+
+func (t *ircTransport) handlePrivmsg(l *ircLine) {
+	target, text := l.param(0), l.param(1)
+	notice := l.Command == "NOTICE"
+	if strings.HasPrefix(target, "#") == false {
+		// there are no private messages between users
+		if notice == false {
+			t.reply("401", target+" :No such nick/channel")
+		}
+		return
+	}
+	room := t.channelRoom(target)
+	if t.c.hub.InRoom(t.c, room) == false {
+		if notice == false {
+			t.reply("404", target+" :Cannot send to channel")
+		}
+		return
+	}
+	if text == "" {
+		if notice == false {
+			t.reply("412", ":No text to send")
+		}
+		return
+	}
+	if strings.HasPrefix(text, "\x01") {
+		// ctcp, only ACTION is understood
+		text = strings.Trim(text, "\x01")
+		if strings.HasPrefix(text, "ACTION ") == false {
+			return
+		}
+		text = "* " + t.getNick() + " " + strings.TrimPrefix(text, "ACTION ")
+	}
+	op := MessageOp
+	if notice {
+		op = NoticeOp
+	}
+	t.pending = append(t.pending, &Message{Op: op, Room: room, From: t.getNick(), Message: text})
+}
+
+// WriteMessage renders hub messages as irc commands. The clients own messages
+// and joins are not echoed, irc clients display them locally.
+func (t *ircTransport) WriteMessage(m *Message) error {
+	if t.isRegistered() == false {
+		return nil
+	}
+	self := m.connection != nil && m.connection == t.c
+	channel := roomToChannel(m.Room)
+
+	switch m.Op {
+	case JoinOp, PartOp:
+		if self || m.From == "" {
+			return nil
+		}
+		command := "JOIN"
+		if m.Op == PartOp {
+			command = "PART"
+		}
+		return t.writeLine(":%s %s %s", t.prefix(m.From), command, channel)
+	case TopicOp:
+		return t.writeLine(":%s TOPIC %s :%s", t.prefix(m.From), channel, m.Message)
+	case NoticeOp:
+		if self {
+			return nil
+		}
+		source := ircServerName
+		if m.From != "" {
+			source = t.prefix(m.From)
+		}
+		return t.writeLines(source, "NOTICE", channel, m.Message)
+	}
+
+	if (m.Message == "" && len(m.Attachments) == 0) || m.From == "" {
+		return nil
+	}
+	if self && m.Op == MessageOp {
+		return nil
+	}
+	text := m.Message
+	for _, a := range m.Attachments {
+		text += fmt.Sprintf("\n[%s] %s", a.Name, a.URL)
+	}
+	if m.Op == EditOp {
+		text = "(edited) " + text
+	}
+	if m.Op == ReactionOp {
+		if m.Remove {
+			return nil
+		}
+		text = "\x01ACTION reacted with " + text + "\x01"
+	}
+	return t.writeLines(t.prefix(m.From), "PRIVMSG", channel, text)
+}
+
+func (t *ircTransport) writeLines(source, command, target, text string) error {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		err := t.writeLine(":%s %s %s :%s", source, command, target, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *ircTransport) Ping() error {
+	if t.isRegistered() == false {
+		return nil
+	}
+	return t.writeLine("PING :%s", ircServerName)
+}
+
+func (t *ircTransport) Close() error {
+	return t.conn.Close()
+}
+
+func (t *ircTransport) RemoteAddr() string {
+	return t.conn.RemoteAddr().String()
+}
+
+// ServeIRC accepts irc client connections on l and registers each with the
+// hub. Rooms are exposed as channels, the default room is #chat. It returns
+// when the listener fails.
+func (h *Handler) ServeIRC(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		log.Printf("irc connection from %s", conn.RemoteAddr())
+		go h.ServeTransport(newIRCTransport(conn), "", "")
+	}
+}
@@ -0,0 +1,196 @@
+package webchat
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// ircClient is a scripted irc client
+type ircClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialIRC(t *testing.T, addr, nick string) *ircClient {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	c := &ircClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	c.send("NICK " + nick)
+	c.send("USER " + nick + " 0 * :" + nick)
+	c.expect(" 001 " + nick + " ")
+	c.expect(" JOIN " + ircDefaultChannel)
+	return c
+}
+
+func (c *ircClient) send(line string) {
+	c.t.Helper()
+	_, err := fmt.Fprintf(c.conn, "%s\r\n", line)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// expect reads lines until one contains want
+func (c *ircClient) expect(want string) string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("waiting for %q: %s", want, err)
+		}
+		if strings.Contains(line, want) {
+			return line
+		}
+	}
+}
+
+func TestIRC(t *testing.T) {
+	hub := NewHub()
+	broadcast := func(op OpCode, hub *Hub, c *Connection, m *Message) error {
+		hub.SendBroadcast(m)
+		return nil
+	}
+	for _, op := range []OpCode{MessageOp, JoinOp, PartOp, TopicOp} {
+		hub.OnCallback(op, broadcast)
+	}
+	go hub.Start()
+	h, err := NewHandler(hub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go h.ServeIRC(l)
+
+	alice := dialIRC(t, l.Addr().String(), "alice")
+	bob := dialIRC(t, l.Addr().String(), "bob")
+
+	alice.send("JOIN #Dev")
+	alice.expect(":alice!alice@webchat JOIN #dev")
+	alice.expect(" 353 alice = #dev :alice")
+
+	// channel names are case insensitive
+	bob.send("JOIN #DEV")
+	bob.expect(":bob!bob@webchat JOIN #dev")
+	alice.expect(":bob!bob@webchat JOIN #dev")
+
+	alice.send("PRIVMSG #dEv :hello bob")
+	bob.expect(":alice!alice@webchat PRIVMSG #dev :hello bob")
+
+	bob.send("TOPIC #dev :release friday")
+	alice.expect(":bob!bob@webchat TOPIC #dev :release friday")
+	alice.send("TOPIC #DEV")
+	alice.expect(" 332 alice #dev :release friday")
+
+	bob.send("PRIVMSG #nowhere :hi")
+	bob.expect(" 404 bob #nowhere ")
+
+	// the admin api reads names while they change
+	stop := make(chan bool)
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				hub.Connections()
+			}
+		}
+	}()
+	bob.send("NICK robert")
+	bob.send("PRIVMSG #dev :renamed")
+	alice.expect(":robert!robert@webchat PRIVMSG #dev :renamed")
+	alice.send("NAMES #dev")
+	alice.expect(" 353 alice = #dev :alice robert")
+
+	alice.send("QUIT")
+	alice.expect("ERROR :Closing link")
+}
+
+func TestIRCLower(t *testing.T) {
+	rooms := []string{"Dev", "ops"}
+	for channel, room := range map[string]string{
+		"#CHAT":     DefaultRoom,
+		"#dev":      "Dev",
+		"#OPS":      "ops",
+		"#new[]\\~": "new{}|^",
+	} {
+		if got := channelToRoom(channel, rooms); got != room {
+			t.Errorf("channelToRoom(%s) = %s, want %s", channel, got, room)
+		}
+	}
+}
+
+func TestIRCWriteLineUTF8(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	tr := newIRCTransport(server)
+	go tr.writeLine("PRIVMSG #chat :%s", strings.Repeat("é", ircMaxLine))
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(line) > ircMaxLine {
+		t.Errorf("line of %d bytes", len(line))
+	}
+	if utf8.ValidString(line) == false {
+		t.Errorf("line was cut inside a rune")
+	}
+}
+
+func TestIRCInjection(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	tr := newIRCTransport(server)
+	tr.registered = true
+	m := &Message{Op: TopicOp, From: "eve\r\nQUIT", Room: "dev", Message: "topic\r\nPRIVMSG #chat :injected\x00"}
+	go tr.WriteMessage(m)
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(line, ":eve__QUIT!eve__QUIT@webchat TOPIC #dev :topic  PRIVMSG") == false {
+		t.Errorf("line %q", line)
+	}
+	if strings.ContainsAny(strings.TrimSuffix(line, "\r\n"), "\r\n\x00") {
+		t.Errorf("line %q was not cleaned", line)
+	}
+}
+
+func TestCheckNames(t *testing.T) {
+	for _, c := range []struct {
+		from, room string
+		ok         bool
+	}{
+		{"alice", "dev", true},
+		{"alice smith", DefaultRoom, true},
+		{"alice\r\nQUIT", "dev", false},
+		{"alice\x00", "dev", false},
+		{"\x1b[2Jalice", "dev", false},
+		{strings.Repeat("a", maxNameSize+1), "dev", false},
+		{"alice", "dev\r\nPRIVMSG #chat :hi", false},
+		{"alice", "dev ops", false},
+		{"alice", "dev,ops", false},
+		{"alice", "\u009bdev", false},
+		{"alice", "\x9bdev", false},
+	} {
+		err := (&Message{From: c.from, Room: c.room}).checkNames()
+		if (err == nil) != c.ok {
+			t.Errorf("checkNames(%q, %q) = %v", c.from, c.room, err)
+		}
+	}
+}
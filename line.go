@@ -0,0 +1,383 @@
+package webchat
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const lineHelp = `commands:
+  /nick <name>  set your nick name
+  /search <q>   search history, ie /search from:bob after:2020-01-31 link
+  /reply <text> reply in the thread of the last message
+  /thread       show the thread of the last message
+  /react <e>    react to the last message with an emoji, /unreact <e> to undo
+  /highlight <w> comma separated words that ring the bell, empty to stop
+  /edit <text>  replace the text of your last message
+  /delete       delete your last message
+  /quit         disconnect
+  /help         this help
+`
+
+// lineTransport speaks a line based text protocol so users can chat with nc
+// or telnet. Lines are sent as MessageOp, /nick maps to NickOp.
+type lineTransport struct {
+	frameBytes
+
+	rw      io.ReadWriteCloser
+	remote  string
+	scanner *bufio.Scanner
+	mx      sync.Mutex
+	nick    string
+
+	c *Connection
+
+	// MessageId of the last message we sent, for /edit and /delete
+	lastId int64
+
+	// thread root of the last message we received, for /reply and /thread
+	lastThread int64
+
+	// MessageId of the last message we received, for /react
+	lastSeen int64
+
+	// messages to send to the hub before reading from the peer
+	pending []*Message
+}
+
+// longLines splits lines like bufio.ScanLines, but a line that does not fit
+// in max bytes is returned cut at max and the rest of it is skipped rather
+// than failing the scanner
+type longLines struct {
+	max      int
+	skipping bool
+}
+
+func (l *longLines) split(data []byte, atEOF bool) (int, []byte, error) {
+	if l.skipping {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			return len(data), nil, nil
+		}
+		l.skipping = false
+		return i + 1, nil, nil
+	}
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	if advance == 0 && err == nil && len(data) >= l.max {
+		l.skipping = true
+		return len(data), data, nil
+	}
+	return advance, token, err
+}
+
+func newLineTransport(rw io.ReadWriteCloser, remote string) *lineTransport {
+	scanner := bufio.NewScanner(rw)
+	scanner.Buffer(make([]byte, 0, maxFrameSize), maxFrameSize)
+	scanner.Split((&longLines{max: maxFrameSize}).split)
+	return &lineTransport{
+		rw:      rw,
+		remote:  remote,
+		scanner: scanner,
+	}
+}
+
+// setNick sets the initial nick and announces it to the hub
+func (t *lineTransport) setNick(nick string) {
+	t.nick = nick
+	t.pending = append(t.pending, &Message{Op: NickOp, From: nick})
+}
+
+func (t *lineTransport) attach(c *Connection) {
+	t.c = c
+}
+
+func (t *lineTransport) writeString(s string) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if conn, ok := t.rw.(net.Conn); ok {
+		conn.SetWriteDeadline(time.Now().Add(writeWait))
+	}
+	n, err := io.WriteString(t.rw, s)
+	t.wrote(n)
+	return err
+}
+
+func (t *lineTransport) ReadMessage() (*Message, error) {
+	if len(t.pending) > 0 {
+		m := t.pending[0]
+		t.pending = t.pending[1:]
+		return m, nil
+	}
+	for t.scanner.Scan() {
+		t.read(len(t.scanner.Bytes()) + 1)
+		line := strings.TrimRight(t.scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line) > maxMessageSize {
+			t.writeString(fmt.Sprintf("*** line too long, at most %d bytes\n", maxMessageSize))
+			continue
+		}
+		if strings.HasPrefix(line, "/") == false {
+			if t.nick == "" {
+				t.writeString("*** set a nick first with /nick <name>\n")
+				continue
+			}
+			return &Message{Op: MessageOp, From: t.nick, Message: line}, nil
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "/nick":
+			if len(fields) != 2 {
+				t.writeString("*** usage: /nick <name>\n")
+				continue
+			}
+			t.nick = fields[1]
+			return &Message{Op: NickOp, From: t.nick}, nil
+		case "/search":
+			if len(fields) < 2 {
+				t.writeString("*** usage: /search <words> [from:nick] [in:room] [after:date] [before:date]\n")
+				continue
+			}
+			return &Message{Op: SearchOp, Message: strings.Join(fields[1:], " ")}, nil
+		case "/reply", "/thread":
+			t.mx.Lock()
+			root := t.lastThread
+			t.mx.Unlock()
+			if root == 0 {
+				t.writeString("*** there is no message to reply to yet\n")
+				continue
+			}
+			if fields[0] == "/thread" {
+				return &Message{Op: ThreadOp, ReplyTo: root}, nil
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(line, "/reply"))
+			if text == "" || t.nick == "" {
+				t.writeString("*** usage: /reply <text>, after setting a nick\n")
+				continue
+			}
+			return &Message{Op: MessageOp, From: t.nick, Message: text, ReplyTo: root}, nil
+		case "/react", "/unreact":
+			t.mx.Lock()
+			id := t.lastSeen
+			t.mx.Unlock()
+			if len(fields) != 2 || id == 0 {
+				t.writeString(fmt.Sprintf("*** usage: %s <emoji>, after a message arrived\n", fields[0]))
+				continue
+			}
+			return &Message{Op: ReactionOp, MessageId: id, Message: fields[1], Remove: fields[0] == "/unreact"}, nil
+		case "/highlight":
+			return &Message{Op: KeywordsOp, Message: strings.TrimSpace(strings.TrimPrefix(line, "/highlight"))}, nil
+		case "/edit", "/delete":
+			t.mx.Lock()
+			id := t.lastId
+			t.mx.Unlock()
+			if id == 0 {
+				t.writeString("*** you have not sent a message yet\n")
+				continue
+			}
+			if fields[0] == "/delete" {
+				return &Message{Op: DeleteOp, MessageId: id}, nil
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(line, "/edit"))
+			if text == "" {
+				t.writeString("*** usage: /edit <text>\n")
+				continue
+			}
+			return &Message{Op: EditOp, MessageId: id, Message: text}, nil
+		case "/quit":
+			return nil, io.EOF
+		case "/help":
+			t.writeString(lineHelp)
+		default:
+			t.writeString(fmt.Sprintf("*** unknown command %s, try /help\n", fields[0]))
+		}
+	}
+	err := t.scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	return nil, err
+}
+
+// StripControl removes the C0 and C1 control characters but newline and tab
+// from s. Text from other users is written to terminals, it may not move the
+// cursor, clear the screen or start escape sequences.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r < 0x20 || r >= 0x7f && r <= 0x9f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// renderLine formats a message for a text terminal, one line per line of the
+// message. Messages without text render as an empty string, the text is not
+// yet passed through StripControl.
+func renderLine(m *Message) string {
+	if m.Op == DeleteOp {
+		return fmt.Sprintf("*** a message from %s was deleted\n", m.From)
+	}
+	if m.Op == ThreadOp {
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "*** thread with %d replies\n", len(m.Results)-1)
+		for _, r := range m.Results {
+			buf.WriteString(renderLine(r))
+		}
+		return buf.String()
+	}
+	if m.Op == ReactionOp {
+		if m.Remove {
+			return ""
+		}
+		return fmt.Sprintf("*** %s reacted with %s\n", m.From, m.Message)
+	}
+	if m.Op == PreviewOp {
+		if m.Preview == nil || m.Preview.Title == "" {
+			return ""
+		}
+		return fmt.Sprintf("*** %s - %s\n", m.Preview.Title, m.Preview.URL)
+	}
+	if m.Op == UnreadOp {
+		rooms := make([]string, 0)
+		for room, n := range m.Unread {
+			if n > 0 {
+				rooms = append(rooms, fmt.Sprintf("%s %d", roomToChannel(room), n))
+			}
+		}
+		if len(rooms) == 0 {
+			return ""
+		}
+		sort.Strings(rooms)
+		return "*** unread: " + strings.Join(rooms, ", ") + "\n"
+	}
+	if m.Message == "" && len(m.Attachments) == 0 {
+		return ""
+	}
+	if m.Op == EditOp {
+		return renderLine(&Message{Op: MessageOp, From: m.From, Message: "(edited) " + m.Message})
+	}
+	if m.Op == SearchOp {
+		var buf strings.Builder
+		fmt.Fprintf(&buf, "*** %d results for %s\n", len(m.Results), m.Message)
+		for _, r := range m.Results {
+			when := time.Unix(0, r.Time*int64(time.Millisecond)).Format("2006-01-02 15:04")
+			fmt.Fprintf(&buf, "*** %s <%s> %s\n", when, r.From, strings.Replace(r.Message, "\n", " ", -1))
+		}
+		return buf.String()
+	}
+	if m.Op == TopicOp {
+		return fmt.Sprintf("*** %s set the topic to %s\n", m.From, m.Message)
+	}
+	prefix := "*** "
+	if m.Op != NoticeOp && m.From != "" {
+		prefix = "<" + m.From + "> "
+		if m.ReplyTo != 0 {
+			prefix += "(reply) "
+		}
+	}
+	var buf strings.Builder
+	for _, line := range strings.Split(m.Message, "\n") {
+		buf.WriteString(prefix)
+		buf.WriteString(strings.TrimRight(line, "\r"))
+		buf.WriteString("\n")
+	}
+	for _, a := range m.Attachments {
+		fmt.Fprintf(&buf, "    [%s, %s] %s\n", a.Name, a.Type, a.URL)
+	}
+	if len(m.Reactions) > 0 {
+		buf.WriteString("    " + formatReactions(m.Reactions) + "\n")
+	}
+	return buf.String()
+}
+
+// formatReactions renders reactions as emoji and count, most popular first
+func formatReactions(reactions map[string][]string) string {
+	emoji := make([]string, 0, len(reactions))
+	for e := range reactions {
+		emoji = append(emoji, e)
+	}
+	sort.Slice(emoji, func(i, j int) bool {
+		if len(reactions[emoji[i]]) != len(reactions[emoji[j]]) {
+			return len(reactions[emoji[i]]) > len(reactions[emoji[j]])
+		}
+		return emoji[i] < emoji[j]
+	})
+	parts := make([]string, 0, len(emoji))
+	for _, e := range emoji {
+		parts = append(parts, fmt.Sprintf("%s %d", e, len(reactions[e])))
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (t *lineTransport) WriteMessage(m *Message) error {
+	if m.Op == MessageOp && m.connection != nil && m.connection == t.c {
+		t.mx.Lock()
+		t.lastId = m.MessageId
+		t.mx.Unlock()
+	}
+	if m.Op == MessageOp && m.Room == DefaultRoom {
+		t.mx.Lock()
+		t.lastThread = m.MessageId
+		t.lastSeen = m.MessageId
+		if m.ReplyTo != 0 {
+			t.lastThread = m.ReplyTo
+		}
+		t.mx.Unlock()
+	}
+	s := StripControl(renderLine(m))
+	if s == "" {
+		return nil
+	}
+	if m.Notify {
+		// ring the terminal bell
+		s = "\a" + s
+	}
+	return t.writeString(s)
+}
+
+// Ping is a no-op, dead peers are detected with tcp keepalives
+func (t *lineTransport) Ping() error {
+	return nil
+}
+
+func (t *lineTransport) Close() error {
+	return t.rw.Close()
+}
+
+func (t *lineTransport) RemoteAddr() string {
+	return t.remote
+}
+
+// ServeLine accepts line protocol connections on l and registers each with
+// the hub. It returns when the listener fails.
+func (h *Handler) ServeLine(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		if tc, ok := conn.(*net.TCPConn); ok {
+			tc.SetKeepAlive(true)
+			tc.SetKeepAlivePeriod(pingPeriod)
+		}
+		log.Printf("line connection from %s", conn.RemoteAddr())
+		go func() {
+			t := newLineTransport(conn, conn.RemoteAddr().String())
+			t.writeString("*** welcome, set a nick with /nick <name>. /help for help\n")
+			h.ServeTransport(t, "", "")
+		}()
+	}
+}
@@ -0,0 +1,21 @@
+package webchat
+
+import (
+	"testing"
+)
+
+func TestStripControl(t *testing.T) {
+	for in, want := range map[string]string{
+		"hello\tworld\n":              "hello\tworld\n",
+		"\x1b[2Jcleared":              "[2Jcleared",
+		"\x1b]0;title\x07text":        "]0;titletext",
+		"spoof\r<admin> hi":           "spoof<admin> hi",
+		"\u009b31mred é":              "31mred é",
+		"\x9bbroken":                  "�broken",
+		"back\bspace\x7f and \x00nul": "backspace and nul",
+	} {
+		if got := StripControl(in); got != want {
+			t.Errorf("StripControl(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package webchat
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Time a poll request is held open waiting for messages
+	pollWait = 25 * time.Second
+
+	// Maximum number of messages returned by a single poll
+	maxPollMessages = 100
+)
+
+// pollTransport queues messages until the peer collects them with a poll
+// request. Messages from the peer arrive through ServeSend.
+type pollTransport struct {
+	inbox
+	out      chan *Message
+	remote   string
+	mx       sync.Mutex
+	lastPoll time.Time
+	polling  int
+}
+
+func (t *pollTransport) WriteMessage(m *Message) error {
+	select {
+	case t.out <- m:
+		return nil
+	case <-t.done:
+		return fmt.Errorf("session closed")
+	case <-time.After(writeWait):
+		return fmt.Errorf("poll queue full")
+	}
+}
+
+// Ping expires the session when the peer has stopped polling
+func (t *pollTransport) Ping() error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.polling == 0 && time.Since(t.lastPoll) > pongWait {
+		return fmt.Errorf("poll timeout")
+	}
+	return nil
+}
+
+func (t *pollTransport) RemoteAddr() string {
+	return t.remote
+}
+
+func (t *pollTransport) poll(r *http.Request) []*Message {
+	t.mx.Lock()
+	t.polling++
+	t.mx.Unlock()
+	defer func() {
+		t.mx.Lock()
+		t.polling--
+		t.lastPoll = time.Now()
+		t.mx.Unlock()
+	}()
+
+	ret := make([]*Message, 0)
+	timer := time.NewTimer(pollWait)
+	defer timer.Stop()
+	select {
+	case m := <-t.out:
+		ret = append(ret, m)
+	case <-timer.C:
+		return ret
+	case <-t.done:
+		return ret
+	case <-r.Context().Done():
+		return ret
+	}
+	for len(ret) < maxPollMessages {
+		select {
+		case m := <-t.out:
+			ret = append(ret, m)
+		default:
+			return ret
+		}
+	}
+	return ret
+}
+
+// ServeLongPoll implements the long poll transport. A POST without a session
+// opens a new session and returns {"session": id}. A GET with the session
+// query parameter waits for messages and returns them as a json array.
+func (h *Handler) ServeLongPoll(w http.ResponseWriter, r *http.Request) {
+	if h.checkOrigin(r) == false {
+		http.Error(w, "Forbidden", 403)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if r.Method == "POST" {
+		identity := clientIdentity(r)
+		t := &pollTransport{
+			inbox:    newInbox(identity),
+			out:      make(chan *Message, 256),
+			remote:   r.RemoteAddr,
+			lastPoll: time.Now(),
+		}
+		session, err := h.addSession(t)
+		if err != nil {
+			log.Printf("poll session: %s", err)
+			http.Error(w, "Internal error", 500)
+			return
+		}
+		go func() {
+			h.ServeTransport(t, identity, identity)
+			h.removeSession(session)
+		}()
+		json.NewEncoder(w).Encode(map[string]string{"session": session})
+		return
+	}
+
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	st := h.getSession(r)
+	t, ok := st.(*pollTransport)
+	if ok == false {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	data, err := json.Marshal(t.poll(r))
+	if err != nil {
+		http.Error(w, "Internal error", 500)
+		return
+	}
+	n, _ := w.Write(data)
+	t.wrote(n)
+}
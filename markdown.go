@@ -0,0 +1,153 @@
+package webchat
+
+import (
+	"html"
+	"net/url"
+	"strings"
+)
+
+// RenderMarkdown renders a markdown subset to html that is safe to insert in
+// a page: **bold**, `code`, ``` code blocks, > quotes, [text](url) links and
+// bare urls. Everything else, including any html in text, is escaped and
+// links are limited to http, https and mailto.
+func RenderMarkdown(text string) string {
+	var buf strings.Builder
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	plain := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "```") {
+			// an unterminated block runs to the end
+			j := i + 1
+			for j < len(lines) && strings.HasPrefix(lines[j], "```") == false {
+				j++
+			}
+			buf.WriteString("<pre><code>")
+			buf.WriteString(html.EscapeString(strings.Join(lines[i+1:j], "\n")))
+			buf.WriteString("</code></pre>")
+			i = j
+			plain = false
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			quoted := make([]string, 0)
+			for ; i < len(lines) && strings.HasPrefix(lines[i], ">"); i++ {
+				quoted = append(quoted, renderInline(strings.TrimPrefix(lines[i][1:], " "), true))
+			}
+			i--
+			buf.WriteString("<blockquote>" + strings.Join(quoted, "<br>") + "</blockquote>")
+			plain = false
+			continue
+		}
+		if plain {
+			buf.WriteString("<br>")
+		}
+		buf.WriteString(renderInline(line, true))
+		plain = true
+	}
+	return buf.String()
+}
+
+// renderInline renders code spans, bold, links and bare urls in a line of
+// text. links is false inside link text so anchors do not nest.
+func renderInline(s string, links bool) string {
+	var buf strings.Builder
+	start := 0
+	flush := func(end int) {
+		buf.WriteString(html.EscapeString(s[start:end]))
+	}
+	for i := 0; i < len(s); {
+		rest := s[i:]
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush(i)
+				buf.WriteString("<code>" + html.EscapeString(rest[1:end+1]) + "</code>")
+				i += end + 2
+				start = i
+				continue
+			}
+		}
+		if strings.HasPrefix(rest, "**") {
+			if end := strings.Index(rest[2:], "**"); end > 0 {
+				flush(i)
+				buf.WriteString("<strong>" + renderInline(rest[2:end+2], links) + "</strong>")
+				i += end + 4
+				start = i
+				continue
+			}
+		}
+		if links && rest[0] == '[' {
+			if text, target, n, ok := parseLink(rest); ok {
+				flush(i)
+				buf.WriteString(anchor(target, renderInline(text, false)))
+				i += n
+				start = i
+				continue
+			}
+		}
+		if links && (strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) && (i == 0 || isWordByte(s[i-1]) == false) {
+			end := strings.IndexAny(rest, " \t<>\"")
+			if end < 0 {
+				end = len(rest)
+			}
+			link := strings.TrimRight(rest[:end], ".,;:!?)'")
+			if _, ok := safeURL(link); ok {
+				flush(i)
+				buf.WriteString(anchor(link, html.EscapeString(link)))
+				i += len(link)
+				start = i
+				continue
+			}
+		}
+		i++
+	}
+	flush(len(s))
+	return buf.String()
+}
+
+// parseLink parses [text](target) at the start of s, returning its length
+func parseLink(s string) (string, string, int, bool) {
+	closeText := strings.Index(s, "](")
+	if closeText < 1 {
+		return "", "", 0, false
+	}
+	closeTarget := strings.IndexByte(s[closeText+2:], ')')
+	if closeTarget < 1 {
+		return "", "", 0, false
+	}
+	target := s[closeText+2 : closeText+2+closeTarget]
+	if _, ok := safeURL(target); ok == false {
+		return "", "", 0, false
+	}
+	return s[1:closeText], target, closeText + 3 + closeTarget, true
+}
+
+// safeURL reports whether target is an absolute http, https or mailto url
+func safeURL(target string) (string, bool) {
+	if strings.ContainsAny(target, " \t\r\n") {
+		return "", false
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+func anchor(target, text string) string {
+	u, _ := safeURL(target)
+	return `<a href="` + html.EscapeString(u) + `" rel="nofollow noopener noreferrer" target="_blank">` + text + `</a>`
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= '0' && b <= '9') || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
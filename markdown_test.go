@@ -0,0 +1,94 @@
+package webchat
+
+import (
+	"regexp"
+	"testing"
+)
+
+const testAnchor = `" rel="nofollow noopener noreferrer" target="_blank">`
+
+func TestRenderMarkdown(t *testing.T) {
+	for _, c := range []struct {
+		in, want string
+	}{
+		// html in text is escaped
+		{"<script>alert(1)</script>", "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{`"><img src=x onerror=alert(1)>`, "&#34;&gt;&lt;img src=x onerror=alert(1)&gt;"},
+		{"> <b>quote\n> **x**", "<blockquote>&lt;b&gt;quote<br><strong>x</strong></blockquote>"},
+		{"```\n<b>\n", "<pre><code>&lt;b&gt;\n</code></pre>"},
+
+		// only http, https and mailto links
+		{"[x](javascript:alert(1))", "[x](javascript:alert(1))"},
+		{"[x](JaVaScRiPt:alert(1))", "[x](JaVaScRiPt:alert(1))"},
+		{"[x](&#106;avascript:alert(1))", "[x](&amp;#106;avascript:alert(1))"},
+		{"[x](java&#x09;script:alert(1))", "[x](java&amp;#x09;script:alert(1))"},
+		{"[x](data:text/html,<script>alert(1)</script>)", "[x](data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;)"},
+		{"[x](//evil.com/)", "[x](//evil.com/)"},
+		{"[x](http:evil)", "[x](http:evil)"},
+		{"see javascript:alert(1)", "see javascript:alert(1)"},
+		{"[m](mailto:a@b.c)", `<a href="mailto:a@b.c` + testAnchor + `m</a>`},
+
+		// quotes can not leave the attribute or the text
+		{`[x](http://a.com/"onmouseover="alert(1))`, `<a href="http://a.com/%22onmouseover=%22alert%281` + testAnchor + `x</a>)`},
+		{"[x](http://a.com/'><script>)", `<a href="http://a.com/%27%3E%3Cscript%3E` + testAnchor + `x</a>`},
+		{`[a"b<i>](http://x.com/)`, `<a href="http://x.com/` + testAnchor + `a&#34;b&lt;i&gt;</a>`},
+		{`see http://x.com/"onmouseover=alert(1)`, `see <a href="http://x.com/` + testAnchor + `http://x.com/</a>&#34;onmouseover=alert(1)`},
+
+		// nesting and unbalanced markers
+		{"[**bold**](http://x.com/)", `<a href="http://x.com/` + testAnchor + `<strong>bold</strong></a>`},
+		{"**[a](http://x.com/)**", `<strong><a href="http://x.com/` + testAnchor + `a</a></strong>`},
+		{"[[a](http://b.com/)](http://c.com/)", `<a href="http://b.com/` + testAnchor + `[a</a>](<a href="http://c.com/` + testAnchor + `http://c.com/</a>)`},
+		{"**`<b>`**", "<strong><code>&lt;b&gt;</code></strong>"},
+		{"**bold", "**bold"},
+		{"`code", "`code"},
+		{"**a [b](http://c.com/) d", `**a <a href="http://c.com/` + testAnchor + `b</a> d`},
+		{"[text](http://x.com/", `[text](<a href="http://x.com/` + testAnchor + `http://x.com/</a>`},
+	} {
+		got := RenderMarkdown(c.in)
+		if got != c.want {
+			t.Errorf("RenderMarkdown(%q)\n got %q\nwant %q", c.in, got, c.want)
+		}
+		checkMarkup(t, c.in, got)
+	}
+}
+
+var (
+	tagRe    = regexp.MustCompile(`<[^>]*>`)
+	anchorRe = regexp.MustCompile(`^<a href="(https?://|mailto:)[^"<>]*` + regexp.QuoteMeta(testAnchor) + `$`)
+)
+
+// checkMarkup fails when out has a tag or link RenderMarkdown does not write
+func checkMarkup(t *testing.T, in, out string) {
+	t.Helper()
+	for _, tag := range tagRe.FindAllString(out, -1) {
+		switch tag {
+		case "<strong>", "</strong>", "<code>", "</code>", "<pre>", "</pre>", "<blockquote>", "</blockquote>", "<br>", "</a>":
+			continue
+		}
+		if anchorRe.MatchString(tag) == false {
+			t.Errorf("RenderMarkdown(%q) wrote %s", in, tag)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	for u, want := range map[string]bool{
+		"http://x.com/":         true,
+		"https://x.com/a?b=c#d": true,
+		"HTTPS://x.com/":        true,
+		"mailto:a@b.c":          true,
+		"javascript:alert(1)":   false,
+		"JaVaScRiPt:alert(1)":   false,
+		" javascript:alert(1)":  false,
+		"data:text/html,<b>":    false,
+		"vbscript:msgbox(1)":    false,
+		"//evil.com/":           false,
+		"http:evil":             false,
+		"/relative":             false,
+		"":                      false,
+	} {
+		if _, ok := safeURL(u); ok != want {
+			t.Errorf("safeURL(%q) = %v, want %v", u, ok, want)
+		}
+	}
+}
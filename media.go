@@ -0,0 +1,422 @@
+package webchat
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// largest image in pixels that is decoded for a thumbnail. the header is
+	// checked first so a small file can not expand into a huge bitmap
+	maxImagePixels = 16 << 20
+
+	// images decoded at once, a 16 bit png of maxImagePixels takes 128MB
+	maxDecoders = 2
+
+	// thumbnails fit in a square of this size
+	thumbnailSize = 320
+
+	// bytes of media kept in memory by the media endpoint
+	mediaCacheSize = 32 << 20
+
+	// path thumbnails and images are served under, relative to the api
+	mediaPath = "/api/media/"
+)
+
+// stripMetadata removes exif, xmp, iptc, comments and text metadata from
+// jpeg, png, webp and gif images. Other types are returned unchanged, images
+// must be strippable. Dropping exif also drops the orientation, thumbnails
+// are rendered as stored.
+func stripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	case "image/gif":
+		return stripGIF(data)
+	}
+	return data, nil
+}
+
+// strippable reports whether stripMetadata handles contentType. Bitmaps and
+// icons have no metadata, other images could carry some.
+func strippable(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp", "image/gif", "image/bmp", "image/x-icon":
+		return true
+	}
+	return strings.HasPrefix(contentType, "image/") == false
+}
+
+// stripJPEG drops APP1 (exif, xmp), APP13 (iptc) and comment segments
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xff || data[1] != 0xd8 {
+		return nil, fmt.Errorf("not a jpeg")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xff {
+			return nil, fmt.Errorf("bad jpeg marker at %d", i)
+		}
+		for i < len(data) && data[i] == 0xff {
+			i++
+		}
+		if i >= len(data) {
+			return nil, fmt.Errorf("truncated jpeg")
+		}
+		marker := data[i]
+		i++
+		if marker == 0xda {
+			// start of scan, the entropy coded data runs to the end
+			out.Write([]byte{0xff, marker})
+			out.Write(data[i:])
+			return out.Bytes(), nil
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd9) {
+			out.Write([]byte{0xff, marker})
+			continue
+		}
+		if i+2 > len(data) {
+			return nil, fmt.Errorf("truncated jpeg")
+		}
+		n := int(binary.BigEndian.Uint16(data[i:]))
+		if n < 2 || i+n > len(data) {
+			return nil, fmt.Errorf("bad jpeg segment length at %d", i)
+		}
+		if marker != 0xe1 && marker != 0xed && marker != 0xfe {
+			out.Write([]byte{0xff, marker})
+			out.Write(data[i : i+n])
+		}
+		i += n
+	}
+	return out.Bytes(), nil
+}
+
+// stripPNG drops the exif, text and time chunks
+func stripPNG(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if bytes.HasPrefix(data, []byte(signature)) == false {
+		return nil, fmt.Errorf("not a png")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.WriteString(signature)
+	i := len(signature)
+	for i+8 <= len(data) {
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		end := i + 12 + n
+		if n < 0 || end > len(data) {
+			return nil, fmt.Errorf("bad png chunk length at %d", i)
+		}
+		switch typ {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+		if typ == "IEND" {
+			break
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the
+// extended header
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, fmt.Errorf("not a webp")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	i := 12
+	for i+8 <= len(data) {
+		typ := string(data[i : i+4])
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + n + n&1
+		if n < 0 || end > len(data) {
+			return nil, fmt.Errorf("bad webp chunk length at %d", i)
+		}
+		switch typ {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			if n < 1 {
+				return nil, fmt.Errorf("bad webp header")
+			}
+			chunk := append([]byte{}, data[i:end]...)
+			chunk[8] &^= 0x08 | 0x04
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	ret := out.Bytes()
+	binary.LittleEndian.PutUint32(ret[4:], uint32(len(ret)-8))
+	return ret, nil
+}
+
+// stripGIF drops comment extensions and application extensions other than
+// the animation loop count, ie xmp
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 || (string(data[:6]) != "GIF87a" && string(data[:6]) != "GIF89a") {
+		return nil, fmt.Errorf("not a gif")
+	}
+	// colorTable returns the size of the color table flagged in packed
+	colorTable := func(packed byte) int {
+		if packed&0x80 == 0 {
+			return 0
+		}
+		return 3 << (packed&0x07 + 1)
+	}
+	// subBlocks returns the end of the data sub-blocks starting at i
+	subBlocks := func(i int) (int, error) {
+		for i < len(data) {
+			n := int(data[i])
+			i += 1 + n
+			if n == 0 {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("truncated gif")
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	i := 13 + colorTable(data[10])
+	if i > len(data) {
+		return nil, fmt.Errorf("truncated gif")
+	}
+	out.Write(data[:i])
+	for i < len(data) {
+		start := i
+		switch data[i] {
+		case 0x3b:
+			out.WriteByte(0x3b)
+			return out.Bytes(), nil
+		case 0x2c:
+			if i+10 > len(data) {
+				return nil, fmt.Errorf("truncated gif")
+			}
+			// descriptor, color table and the lzw code size
+			i += 10 + colorTable(data[i+9]) + 1
+			end, err := subBlocks(i)
+			if err != nil {
+				return nil, err
+			}
+			out.Write(data[start:end])
+			i = end
+		case 0x21:
+			if i+2 > len(data) {
+				return nil, fmt.Errorf("truncated gif")
+			}
+			label := data[i+1]
+			end, err := subBlocks(i + 2)
+			if err != nil {
+				return nil, err
+			}
+			keep := label != 0xfe
+			if label == 0xff {
+				app := data[i+2 : end]
+				keep = bytes.HasPrefix(app, []byte("\x0bNETSCAPE2.0")) || bytes.HasPrefix(app, []byte("\x0bANIMEXTS1.0"))
+			}
+			if keep {
+				out.Write(data[start:end])
+			}
+			i = end
+		default:
+			return nil, fmt.Errorf("bad gif block at %d", i)
+		}
+	}
+	// no trailer, decoders accept that
+	return out.Bytes(), nil
+}
+
+// thumbnail decodes an image no larger than maxImagePixels and renders it to
+// fit thumbnailSize. It returns the encoded thumbnail, its content type and
+// the size of the original.
+func thumbnail(data []byte) ([]byte, string, image.Point, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", image.Point{}, err
+	}
+	size := image.Pt(config.Width, config.Height)
+	if size.X <= 0 || size.Y <= 0 || size.X*size.Y > maxImagePixels {
+		return nil, "", size, fmt.Errorf("image of %dx%d pixels is too large", size.X, size.Y)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", size, err
+	}
+
+	w, h := size.X, size.Y
+	if w > thumbnailSize || h > thumbnailSize {
+		if w > h {
+			w, h = thumbnailSize, h*thumbnailSize/w
+		} else {
+			w, h = w*thumbnailSize/h, thumbnailSize
+		}
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+	}
+	dst := scaleImage(src, w, h)
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+		return buf.Bytes(), "image/jpeg", size, err
+	}
+	err = png.Encode(&buf, dst)
+	return buf.Bytes(), "image/png", size, err
+}
+
+// scaleImage scales src to w by h pixels, averaging the source pixels that
+// fall in each destination pixel
+func scaleImage(src image.Image, w, h int) *image.RGBA64 {
+	b := src.Bounds()
+	dst := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := b.Min.Y + (y+1)*b.Dy()/h
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := b.Min.X + (x+1)*b.Dx()/w
+			if x1 == x0 {
+				x1++
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(bl / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// mediaCache keeps recently served media in memory, least recently used
+// entries are dropped first
+type mediaCache struct {
+	mx      sync.Mutex
+	max     int
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type mediaEntry struct {
+	key         string
+	contentType string
+	data        []byte
+}
+
+func newMediaCache(max int) *mediaCache {
+	return &mediaCache{
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (c *mediaCache) get(key string) (*mediaEntry, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	e, ok := c.entries[key]
+	if ok == false {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*mediaEntry), true
+}
+
+func (c *mediaCache) add(entry *mediaEntry) {
+	if len(entry.data) > c.max/4 {
+		return
+	}
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	if _, ok := c.entries[entry.key]; ok {
+		return
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	c.size += len(entry.data)
+	for c.size > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		dropped := oldest.Value.(*mediaEntry)
+		delete(c.entries, dropped.key)
+		c.size -= len(dropped.data)
+	}
+}
+
+// GET /api/media/{key} serves uploaded images and thumbnails for display in
+// the page. Anything but an image is refused, blobs never change so clients
+// may cache them forever.
+func (a *API) getMedia(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	u := a.hub.uploads
+	if u == nil {
+		http.Error(w, "uploads are disabled", 501)
+		return
+	}
+	key := vars["key"]
+	etag := `"` + key + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(304)
+		return
+	}
+	entry, ok := u.cache.get(key)
+	if ok == false {
+		blob, err := u.store.Get(key)
+		if err != nil {
+			http.Error(w, "Not found", 404)
+			return
+		}
+		data, err := ioutil.ReadAll(blob)
+		blob.Close()
+		if err != nil {
+			http.Error(w, err.Error(), 502)
+			return
+		}
+		entry = &mediaEntry{key: key, contentType: blob.ContentType, data: data}
+		if strings.HasPrefix(entry.contentType, "image/") {
+			u.cache.add(entry)
+		}
+	}
+	if strings.HasPrefix(entry.contentType, "image/") == false {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	w.Header().Set("Content-Type", entry.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry.data)))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Write(entry.data)
+}
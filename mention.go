@@ -0,0 +1,125 @@
+package webchat
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// most keywords a user may set, and their longest length
+	maxKeywords   = 20
+	maxKeywordLen = 64
+)
+
+// mentions of a message, see parseMentions
+type mentions struct {
+	// lower case nicks mentioned with @nick
+	nicks map[string]bool
+
+	// @here or @channel was used, channel also concerns offline users
+	everyone bool
+	channel  bool
+
+	// lower case text and its words, for keywords
+	text  string
+	words map[string]bool
+}
+
+// parseMentions finds @nick, @here and @channel in text
+func parseMentions(text string) *mentions {
+	ms := &mentions{
+		nicks: make(map[string]bool),
+		text:  strings.ToLower(text),
+		words: make(map[string]bool),
+	}
+	for _, word := range tokenize(text) {
+		ms.words[word] = true
+	}
+	for _, field := range strings.Fields(ms.text) {
+		if strings.HasPrefix(field, "@") == false {
+			continue
+		}
+		nick := strings.TrimRight(field[1:], ".,:;!?)")
+		switch nick {
+		case "":
+		case "here":
+			ms.everyone = true
+		case "channel":
+			ms.everyone = true
+			ms.channel = true
+		default:
+			ms.nicks[nick] = true
+		}
+	}
+	return ms
+}
+
+// notify reports whether c is mentioned. The hub lock must be held.
+func (ms *mentions) notify(c *Connection) bool {
+	if ms.everyone || (c.Name != "" && ms.nicks[strings.ToLower(c.Name)]) {
+		return true
+	}
+	return ms.highlights(c.keywords)
+}
+
+// highlights reports whether the text contains one of keywords
+func (ms *mentions) highlights(keywords []string) bool {
+	for _, keyword := range keywords {
+		if words := tokenize(keyword); len(words) == 1 && words[0] == keyword {
+			if ms.words[keyword] {
+				return true
+			}
+		} else if strings.Contains(ms.text, keyword) {
+			// phrases and words with punctuation, ie c++
+			return true
+		}
+	}
+	return false
+}
+
+// ParseKeywords splits a comma separated keyword list
+func ParseKeywords(s string) ([]string, error) {
+	ret := make([]string, 0)
+	for _, keyword := range strings.Split(s, ",") {
+		keyword = strings.ToLower(strings.Join(strings.Fields(keyword), " "))
+		if keyword == "" {
+			continue
+		}
+		if len(keyword) > maxKeywordLen {
+			return nil, fmt.Errorf("keywords may be at most %d bytes", maxKeywordLen)
+		}
+		ret = append(ret, keyword)
+	}
+	if len(ret) > maxKeywords {
+		return nil, fmt.Errorf("at most %d keywords may be set", maxKeywords)
+	}
+	return ret, nil
+}
+
+// setKeywords answers a KeywordsOp from c
+func (h *Hub) setKeywords(c *Connection, s string) {
+	keywords, err := ParseKeywords(s)
+	if err != nil {
+		h.SendMessage(c, &Message{Op: NoticeOp, Message: err.Error()})
+		return
+	}
+	h.mx.Lock()
+	c.keywords = keywords
+	if h.users != nil && c.Identity != "" {
+		// the keywords are the user's, not the connection's
+		for other := range h.connections {
+			if other.Identity == c.Identity {
+				other.keywords = keywords
+			}
+		}
+	}
+	h.mx.Unlock()
+	if h.users != nil && c.Identity != "" {
+		h.users.SetKeywords(c.Identity, keywords)
+	}
+	text := "highlighting is off"
+	if len(keywords) > 0 {
+		text = "highlighting " + strings.Join(keywords, ", ")
+	}
+	h.SendMessage(c, &Message{Op: NoticeOp, Message: text})
+}
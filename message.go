@@ -28,16 +28,147 @@ const (
 	// a user has changed their nick name
 	NickOp
 
-   // a ping to keep the websocket connection alive
+	// a ping to keep the websocket connection alive
 	PingOp
+
+	// a user has left a room
+	PartOp
+
+	// the topic of a room has changed
+	TopicOp
+
+	// a search of the history, answered with the Results
+	SearchOp
+
+	// the author or a moderator changed the text of MessageId
+	EditOp
+
+	// the author or a moderator deleted MessageId
+	DeleteOp
+
+	// fetch the thread started by ReplyTo, answered with the Results
+	ThreadOp
+
+	// From reacted to MessageId with the emoji in Message, or took the
+	// reaction back when Remove is set
+	ReactionOp
+
+	// set the comma separated keywords in Message that highlight messages
+	KeywordsOp
+
+	// the sender has read Room up to MessageId
+	ReadOp
+
+	// request unread counts, answered with Unread
+	UnreadOp
+
+	// acknowledges the Receipt of MessageId. Without a MessageId it switches
+	// the connection to at-least-once delivery. Senders that asked for
+	// WantReceipts get one back for every recipient.
+	AckOp
+
+	// the server fetched a Preview of the link in MessageId
+	PreviewOp
+
+	// the first message of the server and of clients that know it, with
+	// their protocol Version and Features
+	HelloOp
 )
 
 type Message struct {
 	connection *Connection
-	Id         int64  `json:"id"`
-	Op         OpCode `json:"op"`
-	From       string `json:"from"`
-	Message    string `json:"message"`
+
+	// Id is the connection id of the sender
+	Id      int64  `json:"id"`
+	Op      OpCode `json:"op"`
+	From    string `json:"from"`
+	Message string `json:"message"`
+
+	// Html is Message rendered by RenderMarkdown, safe to insert in a page
+	Html string `json:"html,omitempty"`
+
+	// Data is the json payload of the op, of the type registered with
+	// RegisterOpCode. Meta holds small application defined attributes.
+	Data json.RawMessage   `json:"data,omitempty"`
+	Meta map[string]string `json:"meta,omitempty"`
+
+	// Room the message belongs to, empty for the default room
+	Room string `json:"room,omitempty"`
+
+	// MessageId is assigned by the hub, it is unique and increasing
+	MessageId int64 `json:"message_id,omitempty"`
+
+	// Time the hub received the message in unix milliseconds
+	Time int64 `json:"time,omitempty"`
+
+	// Results of a SearchOp, newest first
+	Results []*Message `json:"results,omitempty"`
+
+	// Edited is the time of the last edit in unix milliseconds
+	Edited int64 `json:"edited,omitempty"`
+
+	// Deleted is set on messages removed with DeleteOp, their text is gone
+	Deleted bool `json:"deleted,omitempty"`
+
+	// ReplyTo is the MessageId of the thread root this message replies to
+	ReplyTo int64 `json:"reply_to,omitempty"`
+
+	// Replies and LastReply are kept on thread roots by the History,
+	// LastReply is in unix milliseconds
+	Replies   int   `json:"replies,omitempty"`
+	LastReply int64 `json:"last_reply,omitempty"`
+
+	// Reactions are the names of the users by emoji, kept on messages by
+	// the History
+	Reactions map[string][]string `json:"reactions,omitempty"`
+
+	// reactors are the Authors behind the names in Reactions, in the same
+	// order
+	reactors map[string][]string
+
+	// Remove is set on a ReactionOp that takes a reaction back
+	Remove bool `json:"remove,omitempty"`
+
+	// Unread message counts by room, on UnreadOp
+	Unread map[string]int `json:"unread,omitempty"`
+
+	// WantReceipts asks for an AckOp for every recipient of this message
+	WantReceipts bool `json:"want_receipts,omitempty"`
+
+	// Receipt is delivered or read on an AckOp
+	Receipt string `json:"receipt,omitempty"`
+
+	// Preview of the first link in the message, see Previewer
+	Preview *Preview `json:"preview,omitempty"`
+
+	// Attachments are files uploaded by the sender, see Uploads
+	Attachments []*Attachment `json:"attachments,omitempty"`
+
+	// protocol Version and Features on a HelloOp
+	Version  int      `json:"version,omitempty"`
+	Features []string `json:"features,omitempty"`
+
+	// Notify is set on the copy sent to a recipient who was mentioned
+	Notify bool `json:"notify,omitempty"`
+
+	// Author is set by the hub to identify the sender across nick changes,
+	// see MayModify
+	Author string `json:"-"`
+}
+
+// serverFields clears the fields only the server may set on a message read
+// from a client
+func (m *Message) serverFields() {
+	m.Results = nil
+	m.Html = ""
+	m.Preview = nil
+	m.Replies = 0
+	m.LastReply = 0
+	m.Reactions = nil
+	m.Edited = 0
+	m.Deleted = false
+	m.Unread = nil
+	m.Notify = false
 }
 
 func (m *Message) Json() []byte {
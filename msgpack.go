@@ -0,0 +1,590 @@
+package webchat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// deepest nesting of arrays and maps that is decoded
+const maxMsgpackDepth = 32
+
+// json.RawMessage is encoded as the MessagePack of the json it holds
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+)
+
+// MarshalMsgpack encodes v as MessagePack. Structs are encoded as maps keyed
+// by their json field names and omitempty is honoured, like encoding/json.
+func MarshalMsgpack(v interface{}) ([]byte, error) {
+	e := &msgpackEncoder{}
+	err := e.encode(reflect.ValueOf(v))
+	return e.buf, err
+}
+
+// UnmarshalMsgpack decodes MessagePack into the value v points to. Unknown
+// map keys are skipped.
+func UnmarshalMsgpack(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("msgpack: decode into non pointer %T", v)
+	}
+	d := &msgpackDecoder{data: data}
+	err := d.decode(rv.Elem(), 0)
+	if err == nil && d.pos != len(d.data) {
+		err = fmt.Errorf("msgpack: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return err
+}
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *msgpackEncoder) uint(prefix byte, n uint64, size int) {
+	e.byte(prefix)
+	for i := size - 1; i >= 0; i-- {
+		e.byte(byte(n >> (8 * uint(i))))
+	}
+}
+
+// length writes the header of a string, binary, array or map, the fix
+// prefix is used when n is below fixMax
+func (e *msgpackEncoder) length(n int, fix byte, fixMax int, prefix8, prefix16, prefix32 byte) {
+	switch {
+	case n < fixMax:
+		e.byte(fix | byte(n))
+	case n <= math.MaxUint8 && prefix8 != 0:
+		e.uint(prefix8, uint64(n), 1)
+	case n <= math.MaxUint16:
+		e.uint(prefix16, uint64(n), 2)
+	default:
+		e.uint(prefix32, uint64(n), 4)
+	}
+}
+
+func (e *msgpackEncoder) int(n int64) {
+	switch {
+	case n >= 0:
+		e.uintValue(uint64(n))
+	case n >= -32:
+		e.byte(byte(n))
+	case n >= math.MinInt8:
+		e.uint(0xd0, uint64(n), 1)
+	case n >= math.MinInt16:
+		e.uint(0xd1, uint64(n), 2)
+	case n >= math.MinInt32:
+		e.uint(0xd2, uint64(n), 4)
+	default:
+		e.uint(0xd3, uint64(n), 8)
+	}
+}
+
+func (e *msgpackEncoder) uintValue(n uint64) {
+	switch {
+	case n <= 0x7f:
+		e.byte(byte(n))
+	case n <= math.MaxUint8:
+		e.uint(0xcc, n, 1)
+	case n <= math.MaxUint16:
+		e.uint(0xcd, n, 2)
+	case n <= math.MaxUint32:
+		e.uint(0xce, n, 4)
+	default:
+		e.uint(0xcf, n, 8)
+	}
+}
+
+func (e *msgpackEncoder) string(s string) {
+	e.length(len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Invalid:
+		e.byte(0xc0)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.byte(0xc3)
+		} else {
+			e.byte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.uintValue(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.uint(0xcb, math.Float64bits(v.Float()), 8)
+	case reflect.String:
+		if v.Type() == jsonNumberType {
+			return e.number(json.Number(v.String()))
+		}
+		e.string(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		if v.Type() == rawMessageType {
+			return e.json(v.Bytes())
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.length(v.Len(), 0, 0, 0xc4, 0xc5, 0xc6)
+			e.buf = append(e.buf, v.Bytes()...)
+			return nil
+		}
+		e.length(v.Len(), 0x90, 16, 0, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.byte(0xc0)
+			return nil
+		}
+		e.length(v.Len(), 0x80, 16, 0, 0xde, 0xdf)
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := e.encode(iter.Key()); err != nil {
+				return err
+			}
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := msgpackFields(v.Type())
+		present := make([]msgpackField, 0, len(fields))
+		for _, f := range fields {
+			if f.omitEmpty && v.Field(f.index).IsZero() {
+				continue
+			}
+			present = append(present, f)
+		}
+		e.length(len(present), 0x80, 16, 0, 0xde, 0xdf)
+		for _, f := range present {
+			e.string(f.name)
+			if err := e.encode(v.Field(f.index)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// json encodes the value of the json in data
+func (e *msgpackEncoder) json(data []byte) error {
+	if len(data) == 0 {
+		e.byte(0xc0)
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("msgpack: %s", err)
+	}
+	return e.encode(reflect.ValueOf(v))
+}
+
+// number encodes a json number as an integer when it is one
+func (e *msgpackEncoder) number(n json.Number) error {
+	if i, err := n.Int64(); err == nil {
+		e.int(i)
+		return nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		e.uintValue(u)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return fmt.Errorf("msgpack: %s", err)
+	}
+	e.uint(0xcb, math.Float64bits(f), 8)
+	return nil
+}
+
+type msgpackField struct {
+	name      string
+	index     int
+	omitEmpty bool
+}
+
+// msgpackFields lists the exported fields of t by their json names
+func msgpackFields(t reflect.Type) []msgpackField {
+	ret := make([]msgpackField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		field := msgpackField{name: f.Name, index: i}
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				field.name = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				field.omitEmpty = field.omitEmpty || opt == "omitempty"
+			}
+		}
+		ret = append(ret, field)
+	}
+	return ret
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, fmt.Errorf("msgpack: unexpected end of data")
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+// msgpack value kinds returned by header
+const (
+	mpNil = iota
+	mpBool
+	mpInt
+	mpUint
+	mpFloat
+	mpString
+	mpBinary
+	mpArray
+	mpMap
+)
+
+// header reads the type of the next value. Scalars are returned in n, i
+// or f, strings, binaries, arrays and maps return their length in n.
+func (d *msgpackDecoder) header() (kind int, n uint64, i int64, f float64, err error) {
+	b, err := d.next(1)
+	if err != nil {
+		return
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return mpUint, uint64(c), 0, 0, nil
+	case c >= 0xe0:
+		return mpInt, 0, int64(int8(c)), 0, nil
+	case c&0xf0 == 0x80:
+		return mpMap, uint64(c & 0x0f), 0, 0, nil
+	case c&0xf0 == 0x90:
+		return mpArray, uint64(c & 0x0f), 0, 0, nil
+	case c&0xe0 == 0xa0:
+		return mpString, uint64(c & 0x1f), 0, 0, nil
+	}
+	switch c {
+	case 0xc0:
+		return mpNil, 0, 0, 0, nil
+	case 0xc2, 0xc3:
+		return mpBool, uint64(c & 1), 0, 0, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err = d.uint(1 << (c - 0xc4))
+		return mpBinary, n, 0, 0, err
+	case 0xca:
+		n, err = d.uint(4)
+		return mpFloat, 0, 0, float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err = d.uint(8)
+		return mpFloat, 0, 0, math.Float64frombits(n), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err = d.uint(1 << (c - 0xcc))
+		return mpUint, n, 0, 0, err
+	case 0xd0:
+		n, err = d.uint(1)
+		return mpInt, 0, int64(int8(n)), 0, err
+	case 0xd1:
+		n, err = d.uint(2)
+		return mpInt, 0, int64(int16(n)), 0, err
+	case 0xd2:
+		n, err = d.uint(4)
+		return mpInt, 0, int64(int32(n)), 0, err
+	case 0xd3:
+		n, err = d.uint(8)
+		return mpInt, 0, int64(n), 0, err
+	case 0xd9, 0xda, 0xdb:
+		n, err = d.uint(1 << (c - 0xd9))
+		return mpString, n, 0, 0, err
+	case 0xdc, 0xdd:
+		n, err = d.uint(2 << (c - 0xdc))
+		return mpArray, n, 0, 0, err
+	case 0xde, 0xdf:
+		n, err = d.uint(2 << (c - 0xde))
+		return mpMap, n, 0, 0, err
+	}
+	return 0, 0, 0, 0, fmt.Errorf("msgpack: unsupported type 0x%02x", c)
+}
+
+// length checks that n elements of at least one byte each can follow
+func (d *msgpackDecoder) length(n uint64) (int, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, fmt.Errorf("msgpack: length %d exceeds data", n)
+	}
+	return int(n), nil
+}
+
+func (d *msgpackDecoder) decode(v reflect.Value, depth int) error {
+	if depth > maxMsgpackDepth {
+		return fmt.Errorf("msgpack: nested too deep")
+	}
+	if d.pos < len(d.data) && d.data[d.pos] == 0xc0 {
+		d.pos++
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem(), depth)
+	}
+	kind, n, i, f, err := d.header()
+	if err != nil {
+		return err
+	}
+	return d.value(v, depth, kind, n, i, f)
+}
+
+func (d *msgpackDecoder) value(v reflect.Value, depth, kind int, n uint64, i int64, f float64) error {
+	mismatch := func() error {
+		return fmt.Errorf("msgpack: can not decode into %s", v.Type())
+	}
+	if v.Type() == rawMessageType {
+		var generic interface{}
+		if err := d.value(reflect.ValueOf(&generic).Elem(), depth, kind, n, i, f); err != nil {
+			return err
+		}
+		data, err := json.Marshal(generic)
+		if err != nil {
+			return fmt.Errorf("msgpack: %s", err)
+		}
+		v.SetBytes(data)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if kind != mpBool {
+			return mismatch()
+		}
+		v.SetBool(n == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch kind {
+		case mpInt:
+			v.SetInt(i)
+		case mpUint:
+			if n > math.MaxInt64 {
+				return mismatch()
+			}
+			v.SetInt(int64(n))
+		default:
+			return mismatch()
+		}
+		if v.OverflowInt(v.Int()) {
+			return mismatch()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if kind != mpUint || v.OverflowUint(n) {
+			return mismatch()
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		switch kind {
+		case mpFloat:
+			v.SetFloat(f)
+		case mpInt:
+			v.SetFloat(float64(i))
+		case mpUint:
+			v.SetFloat(float64(n))
+		default:
+			return mismatch()
+		}
+	case reflect.String:
+		if kind != mpString && kind != mpBinary {
+			return mismatch()
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 && (kind == mpString || kind == mpBinary) {
+			b, err := d.next(int(n))
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		if kind != mpArray {
+			return mismatch()
+		}
+		length, err := d.length(n)
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), length, length)
+		for j := 0; j < length; j++ {
+			if err := d.decode(s.Index(j), depth+1); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		if kind != mpMap {
+			return mismatch()
+		}
+		length, err := d.length(n)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), length)
+		for j := 0; j < length; j++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := d.decode(key, depth+1); err != nil {
+				return err
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(elem, depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, elem)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if kind != mpMap {
+			return mismatch()
+		}
+		length, err := d.length(n)
+		if err != nil {
+			return err
+		}
+		fields := msgpackFields(v.Type())
+		for j := 0; j < length; j++ {
+			var name string
+			if err := d.decode(reflect.ValueOf(&name).Elem(), depth+1); err != nil {
+				return err
+			}
+			found := false
+			for _, field := range fields {
+				if field.name == name {
+					found = true
+					if err := d.decode(v.Field(field.index), depth+1); err != nil {
+						return err
+					}
+					break
+				}
+			}
+			if found == false {
+				if err := d.skip(depth + 1); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return mismatch()
+		}
+		// decode into the types encoding/json would use
+		var x reflect.Value
+		switch kind {
+		case mpBool:
+			x = reflect.ValueOf(n == 1)
+		case mpInt:
+			x = reflect.ValueOf(i)
+		case mpUint:
+			x = reflect.ValueOf(n)
+		case mpFloat:
+			x = reflect.ValueOf(f)
+		case mpString, mpBinary:
+			x = reflect.New(reflect.TypeOf("")).Elem()
+		case mpArray:
+			x = reflect.New(reflect.TypeOf([]interface{}{})).Elem()
+		case mpMap:
+			x = reflect.New(reflect.TypeOf(map[string]interface{}{})).Elem()
+		default:
+			return mismatch()
+		}
+		if kind >= mpString {
+			if err := d.value(x, depth, kind, n, i, f); err != nil {
+				return err
+			}
+		}
+		v.Set(x)
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// skip reads past the next value
+func (d *msgpackDecoder) skip(depth int) error {
+	if depth > maxMsgpackDepth {
+		return fmt.Errorf("msgpack: nested too deep")
+	}
+	kind, n, _, _, err := d.header()
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case mpString, mpBinary:
+		_, err = d.next(int(n))
+	case mpArray, mpMap:
+		if kind == mpMap {
+			n *= 2
+		}
+		length, err := d.length(n)
+		if err != nil {
+			return err
+		}
+		for j := 0; j < length; j++ {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
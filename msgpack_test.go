@@ -0,0 +1,155 @@
+package webchat
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fullMessage has every field the codecs encode set
+func fullMessage() *Message {
+	return &Message{
+		Id:        7,
+		Op:        MessageOp,
+		From:      "alice",
+		Message:   "hello **world** ünïcode",
+		Html:      "hello <strong>world</strong> ünïcode",
+		Data:      json.RawMessage(`{"big":18446744073709551615,"list":[1,-2,"x",true,null],"neg":-9007199254740993,"nested":{"f":1.5}}`),
+		Meta:      map[string]string{"client": "bot", "empty": ""},
+		Room:      "dev",
+		MessageId: 1 << 40,
+		Time:      1700000000123,
+		Results: []*Message{
+			{Id: 1, Op: MessageOp, From: "bob", Message: "found", MessageId: 3},
+		},
+		Edited:       1700000000456,
+		Deleted:      true,
+		ReplyTo:      5,
+		Replies:      2,
+		LastReply:    1700000000789,
+		Reactions:    map[string][]string{"👍": {"bob", "carol"}},
+		Remove:       true,
+		Unread:       map[string]int{"dev": 3, "": 0},
+		WantReceipts: true,
+		Receipt:      ReceiptRead,
+		Preview: &Preview{
+			URL:         "https://example.com/",
+			Title:       "Example",
+			Description: "an example",
+			SiteName:    "example",
+			Image:       "/media/preview-1",
+		},
+		Attachments: []*Attachment{
+			{URL: "/media/a", Name: "a.png", Size: 1234, Type: "image/png", Thumbnail: "/media/a-thumb", Width: 640, Height: 480},
+		},
+		Version:  ProtocolVersion,
+		Features: []string{"a", "b"},
+		Notify:   true,
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	m := fullMessage()
+	v := reflect.ValueOf(m).Elem()
+	for _, f := range msgpackFields(v.Type()) {
+		if v.Field(f.index).IsZero() {
+			t.Fatalf("field %s is not set in the test message", f.name)
+		}
+	}
+
+	for _, codec := range []Codec{JSONCodec{}, MsgpackCodec{}} {
+		data, err := codec.Marshal(m)
+		if err != nil {
+			t.Fatalf("%s marshal: %s", codec.Name(), err)
+		}
+		got := &Message{}
+		if err := codec.Unmarshal(data, got); err != nil {
+			t.Fatalf("%s unmarshal: %s", codec.Name(), err)
+		}
+		if reflect.DeepEqual(got, m) == false {
+			t.Errorf("%s round trip\n got %s\nwant %s", codec.Name(), got.Json(), m.Json())
+		}
+	}
+}
+
+func TestMsgpackEmpty(t *testing.T) {
+	m := &Message{Op: PingOp}
+	data, err := MarshalMsgpack(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Message{}
+	if err := UnmarshalMsgpack(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(got, m) == false {
+		t.Errorf("got %s, want %s", got.Json(), m.Json())
+	}
+}
+
+func TestMsgpackTruncated(t *testing.T) {
+	data, err := MarshalMsgpack(fullMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(data); n++ {
+		if err := UnmarshalMsgpack(data[:n], &Message{}); err == nil {
+			t.Errorf("decoded %d of %d bytes without an error", n, len(data))
+		}
+	}
+	if err := UnmarshalMsgpack(append(data, 0xc0), &Message{}); err == nil {
+		t.Errorf("decoded trailing bytes without an error")
+	}
+}
+
+func TestMsgpackInvalid(t *testing.T) {
+	deep := func(key string) []byte {
+		b := []byte{0x81, 0xa0 | byte(len(key))}
+		b = append(b, key...)
+		b = append(b, bytes.Repeat([]byte{0x91}, maxMsgpackDepth+1)...)
+		return append(b, 0xc0)
+	}
+	// every level of results is a map and an array
+	results := []byte{}
+	for i := 0; i < maxMsgpackDepth/2+1; i++ {
+		results = append(results, 0x81, 0xa7, 'r', 'e', 's', 'u', 'l', 't', 's', 0x91)
+	}
+	results = append(results, 0x80)
+	for name, data := range map[string][]byte{
+		"empty":           {},
+		"not a map":       {0x93, 0x01, 0x02, 0x03},
+		"map32 length":    {0xdf, 0xff, 0xff, 0xff, 0xff},
+		"str32 length":    {0x81, 0xa4, 'f', 'r', 'o', 'm', 0xdb, 0xff, 0xff, 0xff, 0xff, 'a'},
+		"bin32 length":    {0x81, 0xa4, 'f', 'r', 'o', 'm', 0xc6, 0xff, 0xff, 0xff, 0xff, 'a'},
+		"array32 length":  {0x81, 0xa8, 'f', 'e', 'a', 't', 'u', 'r', 'e', 's', 0xdd, 0xff, 0xff, 0xff, 0xff, 0xc0},
+		"map16 length":    {0x81, 0xa4, 'm', 'e', 't', 'a', 0xde, 0xff, 0xff},
+		"skipped length":  {0x81, 0xa1, 'x', 0xdd, 0x7f, 0xff, 0xff, 0xff},
+		"wrong type":      {0x81, 0xa2, 'o', 'p', 0xa1, 'x'},
+		"int overflow":    {0x81, 0xa2, 'i', 'd', 0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"unsupported":     {0x81, 0xa2, 'i', 'd', 0xd4, 0x00, 0x00},
+		"too deep data":   deep("data"),
+		"too deep skip":   deep("x"),
+		"too deep nested": results,
+	} {
+		if err := UnmarshalMsgpack(data, &Message{}); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		} else if strings.HasPrefix(name, "too") && strings.Contains(err.Error(), "too deep") == false {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+
+	// just deep enough is fine
+	b := []byte{0x81, 0xa4, 'd', 'a', 't', 'a'}
+	b = append(b, bytes.Repeat([]byte{0x91}, maxMsgpackDepth-1)...)
+	b = append(b, 0xc0)
+	m := &Message{}
+	if err := UnmarshalMsgpack(b, m); err != nil {
+		t.Fatalf("nested %d deep: %s", maxMsgpackDepth-1, err)
+	}
+	want := strings.Repeat("[", maxMsgpackDepth-1) + "null" + strings.Repeat("]", maxMsgpackDepth-1)
+	if string(m.Data) != want {
+		t.Errorf("data %s, want %s", m.Data, want)
+	}
+}
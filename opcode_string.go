@@ -1,16 +1,44 @@
-// Code generated by "stringer -type=OpCode"; DO NOT EDIT
+// Code generated by "stringer -type=OpCode"; DO NOT EDIT.
 
 package webchat
 
-import "fmt"
+import "strconv"
 
-const _OpCode_name = "InvalidOpRegisterOpUnregisterOpMessageOpNoticeOpJoinOpNickOpPingOp"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[InvalidOp-0]
+	_ = x[RegisterOp-1]
+	_ = x[UnregisterOp-2]
+	_ = x[MessageOp-3]
+	_ = x[NoticeOp-4]
+	_ = x[JoinOp-5]
+	_ = x[NickOp-6]
+	_ = x[PingOp-7]
+	_ = x[PartOp-8]
+	_ = x[TopicOp-9]
+	_ = x[SearchOp-10]
+	_ = x[EditOp-11]
+	_ = x[DeleteOp-12]
+	_ = x[ThreadOp-13]
+	_ = x[ReactionOp-14]
+	_ = x[KeywordsOp-15]
+	_ = x[ReadOp-16]
+	_ = x[UnreadOp-17]
+	_ = x[AckOp-18]
+	_ = x[PreviewOp-19]
+	_ = x[HelloOp-20]
+}
+
+const _OpCode_name = "InvalidOpRegisterOpUnregisterOpMessageOpNoticeOpJoinOpNickOpPingOpPartOpTopicOpSearchOpEditOpDeleteOpThreadOpReactionOpKeywordsOpReadOpUnreadOpAckOpPreviewOpHelloOp"
 
-var _OpCode_index = [...]uint8{0, 9, 19, 31, 40, 48, 54, 60, 66}
+var _OpCode_index = [...]uint8{0, 9, 19, 31, 40, 48, 54, 60, 66, 72, 79, 87, 93, 101, 109, 119, 129, 135, 143, 148, 157, 164}
 
 func (i OpCode) String() string {
-	if i < 0 || i >= OpCode(len(_OpCode_index)-1) {
-		return fmt.Sprintf("OpCode(%d)", i)
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_OpCode_index)-1 {
+		return "OpCode(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _OpCode_name[_OpCode_index[i]:_OpCode_index[i+1]]
+	return _OpCode_name[_OpCode_index[idx]:_OpCode_index[idx+1]]
 }
@@ -0,0 +1,196 @@
+package webchat
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// apiParam documents a path or query parameter
+type apiParam struct {
+	Name string
+	In   string
+	Type string
+	Doc  string
+}
+
+// apiRoute is a rest endpoint. The request and response values are only used
+// for their types when generating the openapi document.
+type apiRoute struct {
+	Method   string
+	Pattern  string
+	Summary  string
+	Params   []apiParam
+	Request  interface{}
+	Response interface{}
+
+	// RequestType is the content type of the Request, application/json when
+	// empty
+	RequestType string
+
+	// Status is the success status, 200 when zero
+	Status int
+
+	handler func(w http.ResponseWriter, r *http.Request, vars map[string]string)
+}
+
+// match matches path against the pattern, returning {name} segments
+func (route *apiRoute) match(path string) (map[string]string, bool) {
+	want := strings.Split(route.Pattern, "/")
+	got := strings.Split(path, "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+	vars := make(map[string]string)
+	for i := range want {
+		if strings.HasPrefix(want[i], "{") && strings.HasSuffix(want[i], "}") {
+			if got[i] == "" {
+				return nil, false
+			}
+			vars[want[i][1:len(want[i])-1]] = got[i]
+		} else if want[i] != got[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// OpenAPI generates an openapi 3 document from the routes
+func (a *API) OpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{})
+	components := make(schemas)
+	for _, route := range a.routes {
+		op := map[string]interface{}{
+			"summary": route.Summary,
+		}
+		params := make([]interface{}, 0)
+		for _, p := range route.Params {
+			params = append(params, map[string]interface{}{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.In == "path",
+				"description": p.Doc,
+				"schema":      map[string]interface{}{"type": p.Type},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			requestType := route.RequestType
+			if requestType == "" {
+				requestType = "application/json"
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					requestType: map[string]interface{}{"schema": components.jsonSchema(reflect.TypeOf(route.Request))},
+				},
+			}
+		}
+		status := route.Status
+		if status == 0 {
+			status = 200
+		}
+		response := map[string]interface{}{"description": http.StatusText(status)}
+		if route.Response != nil {
+			response["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": components.jsonSchema(reflect.TypeOf(route.Response))},
+			}
+		}
+		op["responses"] = map[string]interface{}{
+			strconv.Itoa(status): response,
+		}
+
+		item, ok := paths[route.Pattern].(map[string]interface{})
+		if ok == false {
+			item = make(map[string]interface{})
+			paths[route.Pattern] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "webchat",
+			"version": "1.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": components,
+		},
+	}
+}
+
+// schemas are the named struct types of the document by name. Each is
+// described once and referred to with $ref, so types that refer to
+// themselves terminate.
+type schemas map[string]interface{}
+
+// jsonSchema describes t the way encoding/json marshals it
+func (s schemas) jsonSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(json.RawMessage{}) {
+		// any json value
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.jsonSchema(t.Elem())}
+	case reflect.Struct:
+		if t.PkgPath() == "time" && t.Name() == "Time" {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, visited := s[t.Name()]; visited == false {
+			// mark it before describing its fields, they may refer to it
+			s[t.Name()] = nil
+			s[t.Name()] = s.structSchema(t)
+		}
+		return ref
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema describes the fields of struct type t
+func (s schemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		properties[name] = s.jsonSchema(f.Type)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
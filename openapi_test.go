@@ -0,0 +1,38 @@
+package webchat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	h, err := NewHandler(NewHub())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := NewAPI(h, NewMemoryHistory(10)).OpenAPI()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := doc["components"].(map[string]interface{})["schemas"].(schemas)
+	message, ok := schemas["Message"].(map[string]interface{})
+	if ok == false {
+		t.Fatalf("Message is not described: %s", data)
+	}
+	// Message refers to itself through Results
+	results := message["properties"].(map[string]interface{})["results"].(map[string]interface{})
+	items := results["items"].(map[string]interface{})
+	if items["$ref"] != "#/components/schemas/Message" {
+		t.Errorf("results items %v", items)
+	}
+	// Data is any json, not base64
+	if schema := message["properties"].(map[string]interface{})["data"].(map[string]interface{}); len(schema) != 0 {
+		t.Errorf("data %v", schema)
+	}
+	if strings.Contains(string(data), `"/api/openapi.json"`) == false {
+		t.Errorf("openapi endpoint missing from paths")
+	}
+}
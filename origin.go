@@ -0,0 +1,80 @@
+package webchat
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// CheckOriginFn decides whether a websocket upgrade request is allowed
+type CheckOriginFn func(r *http.Request) bool
+
+// checkOrigin is installed as the upgraders CheckOrigin. Requests without an
+// Origin header (non browser clients) are allowed, as are same origin requests
+// and origins matching AllowedOrigins. If a CheckOrigin hook is set on the
+// handler it replaces the built in checks.
+func (h *Handler) checkOrigin(r *http.Request) bool {
+	var ok bool
+	if h.CheckOrigin != nil {
+		ok = h.CheckOrigin(r)
+	} else {
+		ok = h.originAllowed(r)
+	}
+	if ok == false {
+		atomic.AddInt64(&h.rejectedOrigins, 1)
+		log.Printf("rejected cross origin upgrade origin:%s host:%s remote:%s\n",
+			r.Header.Get("Origin"), r.Host, r.RemoteAddr)
+	}
+	return ok
+}
+
+func (h *Handler) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, pattern := range h.AllowedOrigins {
+		if MatchOrigin(pattern, u) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchOrigin reports whether origin matches pattern. Patterns are either a
+// bare host (chat.example.com), a host with scheme (https://chat.example.com)
+// or a wildcard subdomain (*.example.com, https://*.example.com). A single "*"
+// matches any origin. Ports must match exactly when given in the pattern.
+func MatchOrigin(pattern string, origin *url.URL) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "*" {
+		return true
+	}
+	if i := strings.Index(pattern, "://"); i >= 0 {
+		if pattern[:i] != strings.ToLower(origin.Scheme) {
+			return false
+		}
+		pattern = pattern[i+3:]
+	}
+	pattern = strings.TrimSuffix(pattern, "/")
+
+	host := strings.ToLower(origin.Host)
+	if strings.Contains(pattern, ":") == false {
+		host = strings.ToLower(origin.Hostname())
+	}
+
+	if strings.HasPrefix(pattern, "*.") {
+		suffix := pattern[1:]
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return host == pattern
+}
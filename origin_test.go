@@ -0,0 +1,69 @@
+package webchat
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	for _, c := range []struct {
+		pattern, origin string
+		want            bool
+	}{
+		{"*", "https://anything.net", true},
+		{"chat.example.com", "https://chat.example.com", true},
+		{"chat.example.com", "http://CHAT.example.com:8080", true},
+		{"chat.example.com", "https://chat.example.com.evil.net", false},
+		{"chat.example.com", "https://evilchat.example.com", false},
+
+		// wildcards match subdomains only
+		{"*.example.com", "https://a.example.com", true},
+		{"*.example.com", "https://a.b.example.com", true},
+		{"*.example.com", "https://example.com", false},
+		{"*.example.com", "https://evil-example.com", false},
+		{"*.example.com", "https://example.com.evil.net", false},
+		{"*.example.com", "https://a.example.com.evil.net", false},
+
+		// schemes and ports must match when given
+		{"https://chat.example.com", "https://chat.example.com", true},
+		{"https://chat.example.com", "http://chat.example.com", false},
+		{"https://*.example.com", "http://a.example.com", false},
+		{"chat.example.com:8443", "https://chat.example.com:8443", true},
+		{"chat.example.com:8443", "https://chat.example.com:443", false},
+		{"chat.example.com:8443", "https://chat.example.com", false},
+		{"https://*.example.com:8443", "https://a.example.com:9443", false},
+
+		// sandboxed frames and file urls send null
+		{"chat.example.com", "null", false},
+		{"*.example.com", "null", false},
+	} {
+		u, err := url.Parse(c.origin)
+		if err != nil {
+			t.Fatalf("parse %s: %s", c.origin, err)
+		}
+		if got := MatchOrigin(c.pattern, u); got != c.want {
+			t.Errorf("MatchOrigin(%q, %q) = %v, want %v", c.pattern, c.origin, got, c.want)
+		}
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	h := &Handler{AllowedOrigins: []string{"*.example.com"}}
+	for origin, want := range map[string]bool{
+		"":                         true,
+		"http://chat.local:8080":   true,
+		"http://chat.local":        false,
+		"https://a.example.com":    true,
+		"https://evil-example.com": false,
+		"null":                     false,
+	} {
+		r := httptest.NewRequest("GET", "http://chat.local:8080/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := h.originAllowed(r); got != want {
+			t.Errorf("origin %q allowed = %v, want %v", origin, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package webchat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+const (
+	// largest Data of a message
+	maxDataSize = 4096
+
+	// most Meta attributes of a message and their largest keys and values
+	maxMetaKeys      = 16
+	maxMetaKeySize   = 64
+	maxMetaValueSize = 256
+)
+
+// Validator is implemented by payloads that check their own fields, it is
+// called after Data decoded into the registered type
+type Validator interface {
+	Validate() error
+}
+
+// validate checks the Data and Meta of m before it is dispatched. Data must
+// be json, and decode without unknown fields into the payload type
+// registered for the op if there is one.
+func (m *Message) validate() error {
+	if len(m.Meta) > maxMetaKeys {
+		return fmt.Errorf("more than %d meta attributes", maxMetaKeys)
+	}
+	for k, v := range m.Meta {
+		if k == "" || len(k) > maxMetaKeySize || len(v) > maxMetaValueSize {
+			return fmt.Errorf("meta attribute %.64q is empty or too large", k)
+		}
+	}
+	if len(m.Data) == 0 {
+		return nil
+	}
+	if len(m.Data) > maxDataSize {
+		return fmt.Errorf("data larger than %d bytes", maxDataSize)
+	}
+	if json.Valid(m.Data) == false {
+		return fmt.Errorf("data is not json")
+	}
+	t := payloadType(m.Op)
+	if t == nil {
+		return nil
+	}
+	payload := reflect.New(t)
+	dec := json.NewDecoder(bytes.NewReader(m.Data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(payload.Interface()); err != nil {
+		return fmt.Errorf("data of %s: %s", opName(m.Op), err)
+	}
+	if v, ok := payload.Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("data of %s: %s", opName(m.Op), err)
+		}
+	}
+	return nil
+}
+
+// DecodeData decodes the Data of m into payload
+func (m *Message) DecodeData(payload interface{}) error {
+	if len(m.Data) == 0 {
+		return fmt.Errorf("message has no data")
+	}
+	return json.Unmarshal(m.Data, payload)
+}
+
+// SetData encodes payload as the Data of m
+func (m *Message) SetData(payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	m.Data = data
+	return nil
+}
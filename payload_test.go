@@ -0,0 +1,80 @@
+package webchat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testVoteOp OpCode = 1049
+
+type testVote struct {
+	Choice string `json:"choice"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+func (v *testVote) Validate() error {
+	if v.Choice == "" {
+		return fmt.Errorf("no choice")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	if payloadType(testVoteOp) == nil {
+		if err := RegisterOpCode("test.vote", testVoteOp, &testVote{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta := func(n int) map[string]string {
+		ret := make(map[string]string)
+		for i := 0; i < n; i++ {
+			ret[fmt.Sprintf("k%d", i)] = "v"
+		}
+		return ret
+	}
+	for _, c := range []struct {
+		name string
+		m    *Message
+		err  string
+	}{
+		{"plain", &Message{Op: MessageOp, Message: "hi"}, ""},
+		{"any json without a payload type", &Message{Op: MessageOp, Data: json.RawMessage(`{"x":[1,2]}`)}, ""},
+		{"not json", &Message{Op: MessageOp, Data: json.RawMessage(`{"x":`)}, "not json"},
+		{"data at the limit", &Message{Op: MessageOp, Data: json.RawMessage(`"` + strings.Repeat("a", maxDataSize-2) + `"`)}, ""},
+		{"data too large", &Message{Op: MessageOp, Data: json.RawMessage(`"` + strings.Repeat("a", maxDataSize-1) + `"`)}, "larger than"},
+
+		{"meta at the limit", &Message{Op: MessageOp, Meta: meta(maxMetaKeys)}, ""},
+		{"too many meta", &Message{Op: MessageOp, Meta: meta(maxMetaKeys + 1)}, "more than"},
+		{"empty meta key", &Message{Op: MessageOp, Meta: map[string]string{"": "v"}}, "empty or too large"},
+		{"meta key too large", &Message{Op: MessageOp, Meta: map[string]string{strings.Repeat("k", maxMetaKeySize+1): "v"}}, "empty or too large"},
+		{"meta value too large", &Message{Op: MessageOp, Meta: map[string]string{"k": strings.Repeat("v", maxMetaValueSize+1)}}, "empty or too large"},
+
+		{"payload", &Message{Op: testVoteOp, Data: json.RawMessage(`{"choice":"yes","weight":2}`)}, ""},
+		{"no payload", &Message{Op: testVoteOp}, ""},
+		{"unknown field", &Message{Op: testVoteOp, Data: json.RawMessage(`{"choice":"yes","admin":true}`)}, `data of test.vote: json: unknown field "admin"`},
+		{"wrong type", &Message{Op: testVoteOp, Data: json.RawMessage(`{"choice":1}`)}, "data of test.vote"},
+		{"not an object", &Message{Op: testVoteOp, Data: json.RawMessage(`["yes"]`)}, "data of test.vote"},
+		{"validator", &Message{Op: testVoteOp, Data: json.RawMessage(`{"weight":2}`)}, "data of test.vote: no choice"},
+	} {
+		err := c.m.validate()
+		switch {
+		case c.err == "" && err != nil:
+			t.Errorf("%s: %s", c.name, err)
+		case c.err != "" && err == nil:
+			t.Errorf("%s: valid, want %s", c.name, c.err)
+		case c.err != "" && strings.Contains(err.Error(), c.err) == false:
+			t.Errorf("%s: %s, want %s", c.name, err, c.err)
+		}
+	}
+}
+
+func TestRegisterOpCodeTaken(t *testing.T) {
+	if err := RegisterOpCode(MessageOp.String(), 1050, nil); err == nil {
+		t.Errorf("registered a built in name")
+	}
+	if err := RegisterOpCode("test.message", MessageOp, nil); err == nil {
+		t.Errorf("registered a built in opcode")
+	}
+}
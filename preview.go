@@ -0,0 +1,374 @@
+package webchat
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// default limits of a Previewer
+	defaultPreviewTimeout  = 5 * time.Second
+	defaultPreviewMaxBytes = 512 << 10
+	defaultPreviewImageMax = 4 << 20
+
+	// how long previews and failures are cached, and how many
+	previewTTL       = time.Hour
+	maxCachedPreview = 1000
+
+	// most fetches in flight, further links are not previewed
+	maxPreviewFetches = 8
+
+	maxPreviewTitle       = 200
+	maxPreviewDescription = 500
+)
+
+// Preview describes the page a message links to
+type Preview struct {
+	URL         string `json:"url"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+
+	// Image is a thumbnail of the page image served by the media endpoint,
+	// pages are never hotlinked
+	Image string `json:"image,omitempty"`
+}
+
+// Previewer fetches title and open graph metadata of links in messages.
+// Only public addresses are contacted, responses are capped in size and time
+// and results are cached.
+type Previewer struct {
+	// Allowed reports whether an address may be contacted, PublicAddr when
+	// nil. Every connection is checked after name resolution, including
+	// redirects.
+	Allowed func(ip net.IP, port int) bool
+
+	Timeout time.Duration
+
+	// MaxBytes of a page and MaxImageBytes of its image that are read
+	MaxBytes      int64
+	MaxImageBytes int64
+
+	client  *http.Client
+	fetches chan bool
+
+	mx    sync.Mutex
+	cache map[string]*cachedPreview
+	order []string
+}
+
+type cachedPreview struct {
+	preview *Preview
+	err     error
+	expires time.Time
+}
+
+func NewPreviewer() *Previewer {
+	p := &Previewer{
+		Timeout:       defaultPreviewTimeout,
+		MaxBytes:      defaultPreviewMaxBytes,
+		MaxImageBytes: defaultPreviewImageMax,
+		fetches:       make(chan bool, maxPreviewFetches),
+		cache:         make(map[string]*cachedPreview),
+	}
+	dialer := &net.Dialer{
+		Timeout: defaultPreviewTimeout,
+		Control: p.control,
+	}
+	p.client = &http.Client{
+		Transport: &http.Transport{
+			// no proxy, it would connect on our behalf unchecked
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: defaultPreviewTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 3 {
+				return fmt.Errorf("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to %s", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return p
+}
+
+// SetPreviewer enables link previews. It must be called before Start.
+func (h *Hub) SetPreviewer(p *Previewer) {
+	h.previewer = p
+}
+
+var blockedNets = parseCIDRs(
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"64:ff9b::/96",
+	"2001:db8::/32",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	ret := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		ret = append(ret, n)
+	}
+	return ret
+}
+
+// PublicAddr allows ports 80 and 443 of addresses that are not loopback,
+// private, link local, multicast or otherwise reserved
+func PublicAddr(ip net.IP, port int) bool {
+	if port != 80 && port != 443 {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// control vets the resolved address of every connection
+func (p *Previewer) control(network, address string, c syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	n, err := strconv.Atoi(port)
+	if ip == nil || err != nil {
+		return fmt.Errorf("bad address %s", address)
+	}
+	allowed := p.Allowed
+	if allowed == nil {
+		allowed = PublicAddr
+	}
+	if allowed(ip, n) == false {
+		return fmt.Errorf("address %s is not allowed", address)
+	}
+	return nil
+}
+
+// get fetches link, reading at most max bytes of a 200 response of one of
+// the given media types
+func (p *Previewer) get(link string, max int64, types ...string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", "webchat link preview")
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		return nil, "", fmt.Errorf("%s: %s", link, res.Status)
+	}
+	contentType := res.Header.Get("Content-Type")
+	ok := false
+	for _, t := range types {
+		ok = ok || strings.HasPrefix(contentType, t)
+	}
+	if ok == false {
+		return nil, "", fmt.Errorf("%s: content type %s", link, contentType)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, max))
+	return data, contentType, err
+}
+
+// Preview returns the preview of link, fetching it when it is not cached.
+// The page image is thumbnailed into uploads, it is left out when uploads
+// is nil.
+func (p *Previewer) Preview(link string, uploads *Uploads) (*Preview, error) {
+	p.mx.Lock()
+	cached, ok := p.cache[link]
+	p.mx.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.preview, cached.err
+	}
+
+	preview, err := p.fetch(link, uploads)
+
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	if _, ok := p.cache[link]; ok == false {
+		p.order = append(p.order, link)
+	}
+	p.cache[link] = &cachedPreview{preview: preview, err: err, expires: time.Now().Add(previewTTL)}
+	if len(p.order) > maxCachedPreview {
+		delete(p.cache, p.order[0])
+		p.order = p.order[1:]
+	}
+	return preview, err
+}
+
+var (
+	titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaRe  = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attrRe  = regexp.MustCompile(`(?s)([a-zA-Z:_-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+func (p *Previewer) fetch(link string, uploads *Uploads) (*Preview, error) {
+	data, _, err := p.get(link, p.MaxBytes, "text/html", "application/xhtml+xml")
+	if err != nil {
+		return nil, err
+	}
+	meta := make(map[string]string)
+	for _, tag := range metaRe.FindAll(data, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attrRe.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(a[1]))] = string(a[2]) + string(a[3]) + string(a[4])
+		}
+		name := attrs["property"]
+		if name == "" {
+			name = attrs["name"]
+		}
+		name = strings.ToLower(name)
+		if _, ok := meta[name]; ok == false && name != "" {
+			meta[name] = previewText(attrs["content"], maxPreviewDescription)
+		}
+	}
+
+	preview := &Preview{
+		URL:         link,
+		Title:       previewText(meta["og:title"], maxPreviewTitle),
+		Description: meta["og:description"],
+		SiteName:    previewText(meta["og:site_name"], maxPreviewTitle),
+	}
+	if preview.Title == "" {
+		if m := titleRe.FindSubmatch(data); m != nil {
+			preview.Title = previewText(string(m[1]), maxPreviewTitle)
+		}
+	}
+	if preview.Description == "" {
+		preview.Description = meta["description"]
+	}
+	if preview.Title == "" && preview.Description == "" {
+		return nil, fmt.Errorf("%s: nothing to preview", link)
+	}
+	if image := meta["og:image"]; image != "" && uploads != nil {
+		preview.Image, err = p.storeImage(link, image, uploads)
+		if err != nil {
+			log.Printf("preview image %s: %s", image, err)
+		}
+	}
+	return preview, nil
+}
+
+// storeImage stores a thumbnail of the image of the page at link in uploads.
+// image may be relative to the page.
+func (p *Previewer) storeImage(link, image string, uploads *Uploads) (string, error) {
+	base, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(image)
+	if err != nil {
+		return "", err
+	}
+	image = base.ResolveReference(ref).String()
+	if _, ok := safeURL(image); ok == false || strings.HasPrefix(image, "http") == false {
+		return "", fmt.Errorf("not an http url")
+	}
+	// one thumbnail per image, previews fetched again after their cache
+	// entry expired reuse it
+	sum := sha256.Sum256([]byte(image))
+	key := "preview-" + hex.EncodeToString(sum[:16])
+	if blob, err := uploads.store.Get(key); err == nil {
+		blob.Close()
+		return uploads.BaseURL + mediaPath + key, nil
+	}
+	data, _, err := p.get(image, p.MaxImageBytes, "image/")
+	if err != nil {
+		return "", err
+	}
+	thumb, contentType, _, err := uploads.thumbnail(data)
+	if err != nil {
+		return "", err
+	}
+	err = uploads.store.Put(key, contentType, bytes.NewReader(thumb), int64(len(thumb)))
+	if err != nil {
+		return "", err
+	}
+	return uploads.BaseURL + mediaPath + key, nil
+}
+
+// previewText unescapes and trims metadata to at most max bytes
+func previewText(s string, max int) string {
+	s = strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+	if len(s) > max {
+		// drop a rune cut in half
+		s = strings.ToValidUTF8(s[:max], "")
+	}
+	return s
+}
+
+var linkRe = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// firstLink returns the first http or https url in text
+func firstLink(text string) string {
+	link := strings.TrimRight(linkRe.FindString(text), ".,;:!?")
+	if _, ok := safeURL(link); ok == false {
+		return ""
+	}
+	return link
+}
+
+// preview fetches a preview of the first link in m in the background and
+// posts a PreviewOp for it
+func (h *Hub) preview(m *Message) {
+	link := firstLink(m.Message)
+	if link == "" {
+		return
+	}
+	select {
+	case h.previewer.fetches <- true:
+	default:
+		log.Printf("preview %s: too many fetches", link)
+		return
+	}
+	room, id := m.Room, m.MessageId
+	go func() {
+		defer func() { <-h.previewer.fetches }()
+		preview, err := h.previewer.Preview(link, h.uploads)
+		if err != nil {
+			log.Printf("preview %s: %s", link, err)
+			return
+		}
+		h.Post(&Message{Op: PreviewOp, Room: room, MessageId: id, Preview: preview})
+	}()
+}
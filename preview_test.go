@@ -0,0 +1,194 @@
+package webchat
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// loopbackPreviewer may contact the test servers, nothing else
+func loopbackPreviewer() *Previewer {
+	p := NewPreviewer()
+	p.Allowed = func(ip net.IP, port int) bool {
+		return ip.IsLoopback()
+	}
+	return p
+}
+
+func previewServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>Plain title</title>
+<meta property="og:title" content="Open &amp; Graph">
+<meta content='the description' property='og:description'>
+<meta property="og:site_name" content="Example">
+<meta property="og:image" content="/image.png">
+</head></html>`)
+	})
+	mux.HandleFunc("/title", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<title>
+  Just a   title </title><meta name="description" content="from the meta tag">`)
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<title>early</title>`+strings.Repeat(" ", 4096)+`<meta property="og:title" content="late">`)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "<title>not html</title>")
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		png.Encode(w, image.NewGray(image.Rect(0, 0, 640, 480)))
+	})
+	mux.Handle("/redirect", http.RedirectHandler("/og", 302))
+	mux.Handle("/loop", http.RedirectHandler("/loop", 302))
+	mux.Handle("/ftp", http.RedirectHandler("ftp://example.com/", 302))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPreview(t *testing.T) {
+	srv := previewServer(t)
+	store, err := NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := NewUploads(store, 1<<20, []string{"image/"})
+	p := loopbackPreviewer()
+
+	preview, err := p.Preview(srv.URL+"/og", uploads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Open & Graph" || preview.Description != "the description" || preview.SiteName != "Example" {
+		t.Errorf("og preview %+v", preview)
+	}
+	if strings.HasPrefix(preview.Image, mediaPath) == false {
+		t.Errorf("og:image was not stored, %q", preview.Image)
+	} else {
+		blob, err := store.Get(strings.TrimPrefix(preview.Image, mediaPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		config, _, err := image.DecodeConfig(blob)
+		blob.Close()
+		if err != nil || config.Width > thumbnailSize {
+			t.Errorf("image is not a thumbnail: %+v %v", config, err)
+		}
+	}
+	again, err := p.Preview(srv.URL+"/og", uploads)
+	if err != nil || again.Image != preview.Image {
+		t.Errorf("the thumbnail was not reused: %q %q %v", again.Image, preview.Image, err)
+	}
+
+	preview, err = p.Preview(srv.URL+"/title", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Title != "Just a title" || preview.Description != "from the meta tag" || preview.Image != "" {
+		t.Errorf("title preview %+v", preview)
+	}
+
+	preview, err = p.Preview(srv.URL+"/redirect", nil)
+	if err != nil || preview.Title != "Open & Graph" {
+		t.Errorf("redirect preview %+v: %v", preview, err)
+	}
+}
+
+func TestPreviewLimits(t *testing.T) {
+	srv := previewServer(t)
+	p := loopbackPreviewer()
+	p.MaxBytes = 1024
+	p.Timeout = 200 * time.Millisecond
+
+	// og:title is past MaxBytes
+	preview, err := p.Preview(srv.URL+"/large", nil)
+	if err != nil || preview.Title != "early" {
+		t.Errorf("large page %+v: %v", preview, err)
+	}
+
+	start := time.Now()
+	_, err = p.Preview(srv.URL+"/slow", nil)
+	if err == nil {
+		t.Errorf("slow page was previewed")
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("timeout took %s", time.Since(start))
+	}
+
+	for _, path := range []string{"/text", "/loop", "/ftp", "/missing"} {
+		if preview, err := p.Preview(srv.URL+path, nil); err == nil {
+			t.Errorf("%s was previewed: %+v", path, preview)
+		}
+	}
+}
+
+func TestPreviewAllowed(t *testing.T) {
+	srv := previewServer(t)
+
+	// the default only contacts public addresses
+	_, err := NewPreviewer().Preview(srv.URL+"/og", nil)
+	if err == nil || strings.Contains(err.Error(), "not allowed") == false {
+		t.Errorf("loopback server was contacted: %v", err)
+	}
+
+	// a redirect to a disallowed address is not followed
+	other := httptest.NewServer(http.RedirectHandler(srv.URL+"/og", 302))
+	defer other.Close()
+	_, port, _ := net.SplitHostPort(other.URL[len("http://"):])
+	p := NewPreviewer()
+	p.Allowed = func(ip net.IP, n int) bool {
+		return ip.IsLoopback() && fmt.Sprint(n) == port
+	}
+	if _, err := p.Preview(other.URL, nil); err == nil || strings.Contains(err.Error(), "not allowed") == false {
+		t.Errorf("redirect was followed: %v", err)
+	}
+
+	for addr, want := range map[string]bool{
+		"127.0.0.1:80":        false,
+		"127.0.0.1:443":       false,
+		"[::1]:443":           false,
+		"10.1.2.3:80":         false,
+		"192.168.1.1:443":     false,
+		"169.254.169.254:80":  false,
+		"100.64.0.1:80":       false,
+		"0.0.0.0:80":          false,
+		"[fe80::1]:443":       false,
+		"93.184.216.34:8080":  false,
+		"93.184.216.34:443":   true,
+		"[2606:4700::1]:443":  true,
+		"[64:ff9b::a00:1]:80": false,
+	} {
+		host, port, _ := net.SplitHostPort(addr)
+		var n int
+		fmt.Sscan(port, &n)
+		if got := PublicAddr(net.ParseIP(host), n); got != want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestPreviewText(t *testing.T) {
+	long := strings.Repeat("é", maxPreviewTitle)
+	got := previewText(long, maxPreviewTitle)
+	if len(got) > maxPreviewTitle || utf8.ValidString(got) == false {
+		t.Errorf("previewText cut a rune: %d bytes", len(got))
+	}
+}
@@ -0,0 +1,198 @@
+package webchat
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+const (
+	// ProtocolVersion is the version of the protocol the server speaks.
+	// Version 1 is the protocol before the HelloOp handshake.
+	ProtocolVersion = 2
+
+	// MinProtocolVersion is the oldest client version that is accepted
+	MinProtocolVersion = 1
+)
+
+// features announced in a HelloOp
+const (
+	FeatureRooms     = "rooms"
+	FeatureEdits     = "edits"
+	FeatureThreads   = "threads"
+	FeatureReactions = "reactions"
+	FeatureMarkdown  = "markdown"
+	FeatureSearch    = "search"
+	FeatureUnread    = "unread"
+	FeatureReceipts  = "receipts"
+	FeatureUploads   = "uploads"
+	FeaturePreviews  = "previews"
+)
+
+// opFeatures are the features a client must support to be sent an op
+var opFeatures = map[OpCode]string{
+	EditOp:     FeatureEdits,
+	DeleteOp:   FeatureEdits,
+	ThreadOp:   FeatureThreads,
+	ReactionOp: FeatureReactions,
+	SearchOp:   FeatureSearch,
+	UnreadOp:   FeatureUnread,
+	AckOp:      FeatureReceipts,
+	PreviewOp:  FeaturePreviews,
+}
+
+// Protocol describes the protocol of the server, it is served by the
+// protocol endpoint so clients need not hard code opcode numbers
+type Protocol struct {
+	Version    int               `json:"version"`
+	MinVersion int               `json:"min_version"`
+	Features   []string          `json:"features"`
+	Opcodes    map[string]OpCode `json:"opcodes"`
+}
+
+var (
+	opcodesMx sync.Mutex
+
+	// opcodes registered by the application, by name, and the types of
+	// their payloads
+	customOpcodes = make(map[string]OpCode)
+	payloadTypes  = make(map[OpCode]reflect.Type)
+)
+
+// RegisterOpCode names an opcode the application defines, ie HistoryOp, so
+// it is published with the built in ones. payload is a value of the type
+// the Data of the op decodes into, messages with Data that does not are
+// rejected. It is nil when the op has no payload.
+func RegisterOpCode(name string, op OpCode, payload interface{}) error {
+	opcodesMx.Lock()
+	defer opcodesMx.Unlock()
+	for existing, o := range opcodes() {
+		if existing == name || o == op {
+			return fmt.Errorf("opcode %s %d is already defined as %s %d", name, op, existing, o)
+		}
+	}
+	customOpcodes[name] = op
+	if payload != nil {
+		t := reflect.TypeOf(payload)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		payloadTypes[op] = t
+	}
+	return nil
+}
+
+// opName returns the name of op, including registered opcodes
+func opName(op OpCode) string {
+	opcodesMx.Lock()
+	defer opcodesMx.Unlock()
+	for name, o := range customOpcodes {
+		if o == op {
+			return name
+		}
+	}
+	return op.String()
+}
+
+// payloadType returns the registered payload type of op, nil when it has none
+func payloadType(op OpCode) reflect.Type {
+	opcodesMx.Lock()
+	defer opcodesMx.Unlock()
+	return payloadTypes[op]
+}
+
+// Opcodes returns the built in and registered opcodes by name
+func Opcodes() map[string]OpCode {
+	opcodesMx.Lock()
+	defer opcodesMx.Unlock()
+	return opcodes()
+}
+
+// opcodes returns the opcodes by name, opcodesMx must be held
+func opcodes() map[string]OpCode {
+	ret := make(map[string]OpCode)
+	for i := range _OpCode_index[:len(_OpCode_index)-1] {
+		op := OpCode(i)
+		ret[op.String()] = op
+	}
+	for name, op := range customOpcodes {
+		ret[name] = op
+	}
+	return ret
+}
+
+// Features returns the features the hub supports
+func (h *Hub) Features() []string {
+	ret := []string{FeatureRooms, FeatureEdits, FeatureThreads, FeatureReactions, FeatureMarkdown}
+	if h.index != nil {
+		ret = append(ret, FeatureSearch)
+	}
+	if h.users != nil {
+		ret = append(ret, FeatureUnread, FeatureReceipts)
+	}
+	if h.uploads != nil {
+		ret = append(ret, FeatureUploads)
+	}
+	if h.previewer != nil {
+		ret = append(ret, FeaturePreviews)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// Protocol returns the description of the protocol served by the endpoint
+func (h *Hub) Protocol() *Protocol {
+	return &Protocol{
+		Version:    ProtocolVersion,
+		MinVersion: MinProtocolVersion,
+		Features:   h.Features(),
+		Opcodes:    Opcodes(),
+	}
+}
+
+// sendHello greets a new connection with the server version and features
+func (h *Hub) sendHello(c *Connection) {
+	h.SendMessage(c, &Message{Op: HelloOp, Version: ProtocolVersion, Features: h.Features()})
+}
+
+// hello answers the HelloOp of a client. Clients that are too old are told
+// so and disconnected, otherwise they are only sent the ops of the features
+// they declared.
+func (h *Hub) hello(c *Connection, m *Message) {
+	if m.Version < MinProtocolVersion {
+		log.Printf("rejecting protocol version %d id:%d\n", m.Version, c.id)
+		h.SendMessage(c, &Message{
+			Op:      NoticeOp,
+			Version: ProtocolVersion,
+			Message: fmt.Sprintf("protocol version %d is not supported, the server speaks versions %d to %d", m.Version, MinProtocolVersion, ProtocolVersion),
+		})
+		h.Kick(c.id)
+		return
+	}
+	features := make(map[string]bool, len(m.Features))
+	for _, f := range m.Features {
+		features[f] = true
+	}
+	h.mx.Lock()
+	c.version = m.Version
+	c.features = features
+	h.mx.Unlock()
+}
+
+// supports reports whether c may be sent op. Clients that did not say hello
+// are sent everything. The hub lock must be held.
+func (c *Connection) supports(op OpCode) bool {
+	feature, ok := opFeatures[op]
+	if ok == false || c.features == nil {
+		return true
+	}
+	return c.features[feature]
+}
+
+// GET /api/protocol
+func (a *API) getProtocol(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	writeJson(w, a.hub.Protocol())
+}
@@ -0,0 +1,75 @@
+package webchat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// longest reaction, emoji with modifiers take several code points
+	maxReactionLen = 32
+
+	// most different reactions on a single message
+	maxReactions = 20
+)
+
+// ValidReaction checks the emoji of a ReactionOp
+func ValidReaction(emoji string) error {
+	if emoji == "" || len(emoji) > maxReactionLen || utf8.ValidString(emoji) == false {
+		return fmt.Errorf("invalid reaction")
+	}
+	if strings.ContainsAny(emoji, " \t\r\n") {
+		return fmt.Errorf("reactions may not contain spaces")
+	}
+	return nil
+}
+
+// applyReaction adds or removes the reaction of author to m, showing it as
+// name. The maps are replaced, not modified, as copies of m share them. It
+// reports whether the reactions changed.
+func applyReaction(m *Message, emoji, author, name string, remove bool) (bool, error) {
+	authors := m.reactors[emoji]
+	i := sort.SearchStrings(authors, author)
+	found := i < len(authors) && authors[i] == author
+	if found != remove {
+		return false, nil
+	}
+	if remove == false && authors == nil && len(m.reactors) >= maxReactions {
+		return false, fmt.Errorf("a message may have at most %d different reactions", maxReactions)
+	}
+
+	reactors := make(map[string][]string, len(m.reactors)+1)
+	reactions := make(map[string][]string, len(m.reactors)+1)
+	for k := range m.reactors {
+		reactors[k] = m.reactors[k]
+		reactions[k] = m.Reactions[k]
+	}
+	names := m.Reactions[emoji]
+	updatedAuthors := make([]string, 0, len(authors)+1)
+	updatedNames := make([]string, 0, len(authors)+1)
+	updatedAuthors = append(updatedAuthors, authors[:i]...)
+	updatedNames = append(updatedNames, names[:i]...)
+	if remove == false {
+		updatedAuthors = append(append(updatedAuthors, author), authors[i:]...)
+		updatedNames = append(append(updatedNames, name), names[i:]...)
+	} else {
+		updatedAuthors = append(updatedAuthors, authors[i+1:]...)
+		updatedNames = append(updatedNames, names[i+1:]...)
+	}
+	if len(updatedAuthors) == 0 {
+		delete(reactors, emoji)
+		delete(reactions, emoji)
+	} else {
+		reactors[emoji] = updatedAuthors
+		reactions[emoji] = updatedNames
+	}
+	if len(reactors) == 0 {
+		reactors = nil
+		reactions = nil
+	}
+	m.reactors = reactors
+	m.Reactions = reactions
+	return true, nil
+}
@@ -0,0 +1,119 @@
+package webchat
+
+import (
+	"fmt"
+)
+
+const (
+	ReceiptDelivered = "delivered"
+	ReceiptRead      = "read"
+
+	// most messages receipts are tracked for
+	maxReceipts = 1000
+)
+
+// receipt tracks the recipients of a message sent with WantReceipts
+type receipt struct {
+	author string
+	room   string
+
+	// ReceiptDelivered or ReceiptRead by recipient Author, and the name
+	// each recipient acknowledged with
+	states map[string]string
+	names  map[string]string
+}
+
+// trackReceipts starts tracking receipts for m. The hub lock must be held.
+func (h *Hub) trackReceipts(m *Message) {
+	h.receipts[m.MessageId] = &receipt{
+		author: m.Author,
+		room:   m.Room,
+		states: make(map[string]string),
+		names:  make(map[string]string),
+	}
+	h.receiptOrder = append(h.receiptOrder, m.MessageId)
+	if len(h.receiptOrder) > maxReceipts {
+		delete(h.receipts, h.receiptOrder[0])
+		h.receiptOrder = h.receiptOrder[1:]
+	}
+}
+
+// Receipts returns the receipt state by recipient name of a message sent
+// with WantReceipts
+func (h *Hub) Receipts(id int64) (map[string]string, error) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	r, ok := h.receipts[id]
+	if ok == false {
+		return nil, fmt.Errorf("no receipts for message %d", id)
+	}
+	ret := make(map[string]string, len(r.states))
+	for recipient, state := range r.states {
+		ret[r.names[recipient]] = state
+	}
+	return ret, nil
+}
+
+// ack answers an AckOp from c
+func (h *Hub) ack(c *Connection, m *Message) {
+	if m.MessageId == 0 {
+		if h.users == nil || c.Identity == "" {
+			h.SendMessage(c, &Message{Op: NoticeOp, Message: "at-least-once delivery needs a verified identity"})
+			return
+		}
+		h.mx.Lock()
+		already := c.acking
+		c.acking = true
+		h.mx.Unlock()
+		h.users.EnableAcks(c.Identity)
+		if already == false {
+			for _, pending := range h.users.Pending(c.Identity) {
+				h.SendMessage(c, pending)
+			}
+		}
+		return
+	}
+
+	state := m.Receipt
+	if state == "" {
+		state = ReceiptDelivered
+	}
+	if state != ReceiptDelivered && state != ReceiptRead {
+		h.SendMessage(c, &Message{Op: NoticeOp, Message: fmt.Sprintf("invalid receipt %s", state)})
+		return
+	}
+	recipient := authorOf(c)
+	h.mx.Lock()
+	r, tracked := h.receipts[m.MessageId]
+	if tracked && c.rooms[r.room] == false {
+		// ids are easy to guess, only members of the room acknowledge
+		h.mx.Unlock()
+		return
+	}
+	room := m.Room
+	if tracked {
+		room = r.room
+	}
+	// untracked, our own or not news are not passed on. read implies
+	// delivered
+	news := tracked && c.Name != "" && r.author != recipient && r.states[recipient] != state && r.states[recipient] != ReceiptRead
+	if news {
+		r.states[recipient] = state
+		r.names[recipient] = c.Name
+	}
+	h.mx.Unlock()
+
+	if h.users != nil && c.Identity != "" {
+		h.users.Ack(c.Identity, m.MessageId)
+		if state == ReceiptRead {
+			h.users.MarkRead(c.Identity, room, m.MessageId)
+		}
+	}
+	if news == false {
+		return
+	}
+	update := &Message{Op: AckOp, Room: r.room, MessageId: m.MessageId, From: c.Name, Receipt: state}
+	for _, sender := range h.AuthorConnections(r.author) {
+		h.SendMessage(sender, update)
+	}
+}
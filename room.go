@@ -0,0 +1,126 @@
+package webchat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultRoom is the room every connection starts in
+const DefaultRoom = ""
+
+// maxNameSize is the longest nick or room name in bytes
+const maxNameSize = 64
+
+// checkName checks a nick or room name, every frontend shows them as they
+// are and they must be utf-8 without control characters
+func checkName(kind, name string) error {
+	if len(name) > maxNameSize {
+		return fmt.Errorf("%s longer than %d bytes", kind, maxNameSize)
+	}
+	if utf8.ValidString(name) == false || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		return fmt.Errorf("%s contains control characters", kind)
+	}
+	return nil
+}
+
+// checkNames checks the sender and room of m. Rooms are irc channels too,
+// they may not contain spaces or commas.
+func (m *Message) checkNames() error {
+	if err := checkName("name", m.From); err != nil {
+		return err
+	}
+	if err := checkName("room", m.Room); err != nil {
+		return err
+	}
+	if strings.ContainsAny(m.Room, " ,") {
+		return fmt.Errorf("room contains spaces or commas")
+	}
+	return nil
+}
+
+// Join adds c to room
+func (h *Hub) Join(c *Connection, room string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	c.rooms[room] = true
+}
+
+// Part removes c from room
+func (h *Hub) Part(c *Connection, room string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	delete(c.rooms, room)
+}
+
+// InRoom reports whether c has joined room
+func (h *Hub) InRoom(c *Connection, room string) bool {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return c.rooms[room]
+}
+
+// Members returns the connections that have joined room
+func (h *Hub) Members(room string) []*Connection {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ret := make([]*Connection, 0)
+	for c := range h.connections {
+		if c.rooms[room] {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// Names returns the names of the connections that have joined room
+func (h *Hub) Names(room string) map[*Connection]string {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	ret := make(map[*Connection]string)
+	for c := range h.connections {
+		if c.rooms[room] {
+			ret[c] = c.Name
+		}
+	}
+	return ret
+}
+
+// Rooms returns the sorted names of rooms with members or a topic
+func (h *Hub) Rooms() []string {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	seen := make(map[string]bool)
+	for c := range h.connections {
+		for room := range c.rooms {
+			seen[room] = true
+		}
+	}
+	for room := range h.topics {
+		seen[room] = true
+	}
+	ret := make([]string, 0, len(seen))
+	for room := range seen {
+		ret = append(ret, room)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+func (h *Hub) Topic(room string) string {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return h.topics[room]
+}
+
+func (h *Hub) SetTopic(room, topic string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if topic == "" {
+		delete(h.topics, room)
+		return
+	}
+	h.topics[room] = topic
+}
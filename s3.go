@@ -0,0 +1,147 @@
+package webchat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Store keeps blobs in a bucket of an S3 compatible service, ie minio.
+// Requests use path style urls and are signed with signature version 4.
+type S3Store struct {
+	// Endpoint is the service url, ie https://s3.us-east-1.amazonaws.com
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKey string
+	SecretKey string
+
+	Client *http.Client
+}
+
+func NewS3Store(endpoint, region, bucket, accessKey, secretKey string) *S3Store {
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Store{
+		Endpoint:  strings.TrimRight(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    &http.Client{Timeout: time.Minute},
+	}
+}
+
+// do sends a signed request for key and fails on anything but a 2xx
+func (s *S3Store) do(method, key string, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.Endpoint+"/"+s.Bucket+"/"+key, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	s.sign(req, time.Now().UTC())
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s %s", method, key, res.Status, msg)
+	}
+	return res, nil
+}
+
+// sign adds the signature version 4 authorization header to req. The
+// payload is not signed so bodies can be streamed.
+func (s *S3Store) sign(req *http.Request, now time.Time) {
+	const payload = "UNSIGNED-PAYLOAD"
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payload,
+	}, "\n")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func (s *S3Store) Put(key, contentType string, r io.Reader, size int64) error {
+	header := http.Header{}
+	header.Set("Content-Type", contentType)
+	res, err := s.do("PUT", key, header, r, size)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (s *S3Store) Get(key string) (*Blob, error) {
+	res, err := s.do("GET", key, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Blob{ReadCloser: res.Body, ContentType: res.Header.Get("Content-Type"), Size: res.ContentLength}, nil
+}
+
+func (s *S3Store) Delete(key string) error {
+	res, err := s.do("DELETE", key, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
@@ -0,0 +1,212 @@
+package webchat
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a bucket that checks the signature version 4 of every request
+type fakeS3 struct {
+	bucket    string
+	accessKey string
+	secretKey string
+
+	mx      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+// verify recomputes the signature of r the way the service does
+func (s *fakeS3) verify(r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "AWS4-HMAC-SHA256 ") == false {
+		return fmt.Errorf("authorization %q", auth)
+	}
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(auth, "AWS4-HMAC-SHA256 "), ", ") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("authorization field %q", field)
+		}
+		fields[kv[0]] = kv[1]
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	date, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return err
+	}
+	if time.Since(date) > 15*time.Minute {
+		return fmt.Errorf("request too old")
+	}
+	scope := amzDate[:8] + "/us-east-1/s3/aws4_request"
+	if fields["Credential"] != s.accessKey+"/"+scope {
+		return fmt.Errorf("credential %q", fields["Credential"])
+	}
+	signed := strings.Split(fields["SignedHeaders"], ";")
+	if sort.StringsAreSorted(signed) == false {
+		return fmt.Errorf("signed headers not sorted")
+	}
+	required := map[string]bool{"host": true, "x-amz-date": true, "x-amz-content-sha256": true}
+	headers := ""
+	for _, name := range signed {
+		delete(required, name)
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers += name + ":" + strings.TrimSpace(value) + "\n"
+	}
+	if len(required) > 0 {
+		return fmt.Errorf("unsigned headers %v", required)
+	}
+	canonical := r.Method + "\n" + r.URL.EscapedPath() + "\n" + r.URL.RawQuery + "\n" +
+		headers + "\n" + fields["SignedHeaders"] + "\n" + r.Header.Get("X-Amz-Content-Sha256")
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{amzDate[:8], "us-east-1", "s3", "aws4_request", toSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if hex.EncodeToString(key) != fields["Signature"] {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.verify(r); err != nil {
+		http.Error(w, "SignatureDoesNotMatch: "+err.Error(), 403)
+		return
+	}
+	prefix := "/" + s.bucket + "/"
+	if strings.HasPrefix(r.URL.Path, prefix) == false {
+		http.Error(w, "NoSuchBucket", 404)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	switch r.Method {
+	case "PUT":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil || int64(len(data)) != r.ContentLength {
+			http.Error(w, "IncompleteBody", 400)
+			return
+		}
+		s.objects[key] = data
+		s.types[key] = r.Header.Get("Content-Type")
+	case "GET":
+		data, ok := s.objects[key]
+		if ok == false {
+			http.Error(w, "NoSuchKey", 404)
+			return
+		}
+		w.Header().Set("Content-Type", s.types[key])
+		w.Write(data)
+	case "DELETE":
+		delete(s.objects, key)
+		delete(s.types, key)
+		w.WriteHeader(204)
+	default:
+		http.Error(w, "MethodNotAllowed", 405)
+	}
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		bucket:    "chat",
+		accessKey: "AKIDEXAMPLE",
+		secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		objects:   make(map[string][]byte),
+		types:     make(map[string]string),
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s := NewS3Store(srv.URL+"/", "", fake.bucket, fake.accessKey, fake.secretKey)
+	data := []byte("hello s3")
+	err := s.Put("0123abcd", "text/plain; charset=utf-8", bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := s.Get("0123abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(got, data) == false {
+		t.Errorf("got %q, want %q", got, data)
+	}
+	if blob.ContentType != "text/plain; charset=utf-8" {
+		t.Errorf("content type %q", blob.ContentType)
+	}
+	if blob.Size != int64(len(data)) {
+		t.Errorf("size %d", blob.Size)
+	}
+
+	err = s.Delete("0123abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get("0123abcd")
+	if err == nil || strings.Contains(err.Error(), "404") == false {
+		t.Errorf("get after delete: %v", err)
+	}
+
+	wrong := NewS3Store(srv.URL, "", fake.bucket, fake.accessKey, "not the secret")
+	err = wrong.Put("0123abcd", "text/plain", bytes.NewReader(data), int64(len(data)))
+	if err == nil || strings.Contains(err.Error(), "403") == false {
+		t.Errorf("put with the wrong secret: %v", err)
+	}
+}
+
+func TestUploadsExpire(t *testing.T) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	u := NewUploads(NewS3Store(srv.URL, "", fake.bucket, fake.accessKey, fake.secretKey), 1<<20, []string{"text/plain"})
+
+	now := time.Now()
+	for key, created := range map[string]time.Time{"old": now.Add(-2 * uploadTTL), "new": now} {
+		err := u.store.Put(key, "text/plain", strings.NewReader(key), int64(len(key)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		u.unattached[key] = &upload{author: "identity:ann", attachment: &Attachment{URL: uploadsPath + key}, created: created}
+	}
+	u.expire(now)
+
+	if _, ok := u.unattached["old"]; ok {
+		t.Errorf("expired upload is still unattached")
+	}
+	if _, ok := fake.objects["old"]; ok {
+		t.Errorf("expired upload was not deleted")
+	}
+	if _, ok := fake.objects["new"]; ok == false {
+		t.Errorf("recent upload was deleted")
+	}
+	if got := u.resolve("identity:ann", []*Attachment{{URL: uploadsPath + "new"}}); len(got) != 1 {
+		t.Errorf("recent upload can not be attached")
+	}
+}
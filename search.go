@@ -0,0 +1,344 @@
+package webchat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// default and maximum number of search results
+	defaultSearchLimit = 20
+	maxSearchLimit     = 200
+)
+
+// SearchQuery selects messages from a SearchIndex. Zero values match
+// everything.
+type SearchQuery struct {
+	// Text must contain every word of Text, case insensitive
+	Text string `json:"text,omitempty"`
+
+	From string `json:"from,omitempty"`
+
+	// Rooms restricts the search to these rooms, nil searches every room
+	Rooms []string `json:"rooms,omitempty"`
+
+	// Since and Until bound Message.Time in unix milliseconds, Until is
+	// exclusive
+	Since int64 `json:"since,omitempty"`
+	Until int64 `json:"until,omitempty"`
+
+	Limit int `json:"limit,omitempty"`
+}
+
+// ParseSearchQuery parses a query typed by a user. Words are matched against
+// the text, except for the filters from:nick, in:room (default for the
+// default room), after:date and before:date. Dates are 2006-01-02 in local
+// time or RFC3339.
+func ParseSearchQuery(s string) (*SearchQuery, error) {
+	q := &SearchQuery{}
+	words := make([]string, 0)
+	for _, field := range strings.Fields(s) {
+		i := strings.Index(field, ":")
+		if i < 1 {
+			words = append(words, field)
+			continue
+		}
+		key, value := field[:i], field[i+1:]
+		var err error
+		switch key {
+		case "from":
+			q.From = value
+		case "in":
+			q.Rooms = append(q.Rooms, apiRoom(value))
+		case "after":
+			q.Since, err = parseSearchTime(value)
+		case "before":
+			q.Until, err = parseSearchTime(value)
+		default:
+			words = append(words, field)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	q.Text = strings.Join(words, " ")
+	return q, nil
+}
+
+func parseSearchTime(s string) (int64, error) {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		t, err = time.Parse(time.RFC3339, s)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid date %s, use 2006-01-02 or RFC3339", s)
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// tokenize splits text into lower case words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return unicode.IsLetter(r) == false && unicode.IsNumber(r) == false
+	})
+}
+
+// SearchIndex is an in memory inverted index over the newest messages
+type SearchIndex struct {
+	mx  sync.RWMutex
+	max int
+
+	// indexed messages by MessageId and their ids in the order added
+	messages map[int64]*Message
+	order    []int64
+
+	// ascending MessageIds by word
+	terms map[string][]int64
+}
+
+// NewSearchIndex returns an index holding at most max messages, evicting the
+// oldest
+func NewSearchIndex(max int) *SearchIndex {
+	return &SearchIndex{
+		max:      max,
+		messages: make(map[int64]*Message),
+		terms:    make(map[string][]int64),
+	}
+}
+
+// Add indexes a copy of m, which must have a MessageId
+func (idx *SearchIndex) Add(m *Message) {
+	if m.MessageId == 0 {
+		return
+	}
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if _, ok := idx.messages[m.MessageId]; ok {
+		return
+	}
+	c := *m
+	c.connection = nil
+	idx.messages[c.MessageId] = &c
+	idx.order = append(idx.order, c.MessageId)
+	for _, term := range uniqueTerms(c.Message) {
+		idx.terms[term] = append(idx.terms[term], c.MessageId)
+	}
+	for len(idx.order) > idx.max {
+		idx.remove(idx.order[0])
+		idx.order = idx.order[1:]
+	}
+}
+
+func uniqueTerms(text string) []string {
+	seen := make(map[string]bool)
+	ret := make([]string, 0)
+	for _, term := range tokenize(text) {
+		if seen[term] == false {
+			seen[term] = true
+			ret = append(ret, term)
+		}
+	}
+	return ret
+}
+
+// remove drops id from the messages and postings but not from order. The
+// lock must be held.
+func (idx *SearchIndex) remove(id int64) {
+	m, ok := idx.messages[id]
+	if ok == false {
+		return
+	}
+	delete(idx.messages, id)
+	for _, term := range uniqueTerms(m.Message) {
+		ids := idx.terms[term]
+		i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+		if i < len(ids) && ids[i] == id {
+			ids = append(ids[:i], ids[i+1:]...)
+		}
+		if len(ids) == 0 {
+			delete(idx.terms, term)
+		} else {
+			idx.terms[term] = ids
+		}
+	}
+}
+
+// Update reindexes a message that was edited
+func (idx *SearchIndex) Update(m *Message) {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	if _, ok := idx.messages[m.MessageId]; ok == false {
+		return
+	}
+	idx.remove(m.MessageId)
+	c := *m
+	c.connection = nil
+	idx.messages[c.MessageId] = &c
+	for _, term := range uniqueTerms(c.Message) {
+		ids := idx.terms[term]
+		i := sort.Search(len(ids), func(i int) bool { return ids[i] >= c.MessageId })
+		ids = append(ids, 0)
+		copy(ids[i+1:], ids[i:])
+		ids[i] = c.MessageId
+		idx.terms[term] = ids
+	}
+}
+
+// Remove drops a deleted message from the index
+func (idx *SearchIndex) Remove(id int64) {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	idx.remove(id)
+}
+
+// Purge removes messages in room, or every room when room is "*"
+func (idx *SearchIndex) Purge(room string) int {
+	idx.mx.Lock()
+	defer idx.mx.Unlock()
+	purged := 0
+	order := idx.order[:0]
+	for _, id := range idx.order {
+		m, ok := idx.messages[id]
+		if ok && (room == "*" || m.Room == room) {
+			idx.remove(id)
+			purged++
+			continue
+		}
+		order = append(order, id)
+	}
+	idx.order = order
+	return purged
+}
+
+// Search returns messages matching q, newest first
+func (idx *SearchIndex) Search(q *SearchQuery) []*Message {
+	limit := q.Limit
+	if limit < 1 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	rooms := make(map[string]bool)
+	for _, room := range q.Rooms {
+		rooms[room] = true
+	}
+
+	idx.mx.RLock()
+	defer idx.mx.RUnlock()
+
+	// candidates are every message, or the postings of the rarest word
+	candidates := idx.order
+	terms := uniqueTerms(q.Text)
+	for i, term := range terms {
+		ids := idx.terms[term]
+		if i == 0 || len(ids) < len(candidates) {
+			candidates = ids
+		}
+	}
+
+	ret := make([]*Message, 0)
+	for i := len(candidates) - 1; i >= 0 && len(ret) < limit; i-- {
+		m, ok := idx.messages[candidates[i]]
+		if ok == false {
+			continue
+		}
+		if q.From != "" && strings.EqualFold(m.From, q.From) == false {
+			continue
+		}
+		if q.Rooms != nil && rooms[m.Room] == false {
+			continue
+		}
+		if (q.Since != 0 && m.Time < q.Since) || (q.Until != 0 && m.Time >= q.Until) {
+			continue
+		}
+		if containsTerms(m.Message, terms) == false {
+			continue
+		}
+		c := *m
+		ret = append(ret, &c)
+	}
+	return ret
+}
+
+func containsTerms(text string, terms []string) bool {
+	if len(terms) == 0 {
+		return true
+	}
+	have := make(map[string]bool)
+	for _, term := range tokenize(text) {
+		have[term] = true
+	}
+	for _, term := range terms {
+		if have[term] == false {
+			return false
+		}
+	}
+	return true
+}
+
+// SetSearchIndex enables search, messages are indexed as they are broadcast.
+// It must be called before Start.
+func (h *Hub) SetSearchIndex(idx *SearchIndex) {
+	h.index = idx
+}
+
+// search answers a SearchOp from c, limited to the rooms c has joined
+func (h *Hub) search(c *Connection, m *Message) {
+	if h.index == nil {
+		h.SendMessage(c, &Message{Op: NoticeOp, Room: m.Room, Message: "search is disabled"})
+		return
+	}
+	q, err := ParseSearchQuery(m.Message)
+	if err != nil {
+		h.SendMessage(c, &Message{Op: NoticeOp, Room: m.Room, Message: err.Error()})
+		return
+	}
+	h.mx.Lock()
+	q.scope(c.rooms)
+	h.mx.Unlock()
+	h.SendMessage(c, &Message{Op: SearchOp, Room: m.Room, Message: m.Message, Results: h.index.Search(q)})
+}
+
+// scope limits q to the joined rooms, all of them when q names none
+func (q *SearchQuery) scope(joined map[string]bool) {
+	rooms := make([]string, 0)
+	if q.Rooms == nil {
+		for room := range joined {
+			rooms = append(rooms, room)
+		}
+	} else {
+		for _, room := range q.Rooms {
+			if joined[room] {
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	q.Rooms = rooms
+}
+
+// joined returns the rooms identity has joined on any of its connections,
+// and with Users the rooms it is remembered in
+func (h *Hub) joined(identity string) map[string]bool {
+	rooms := make(map[string]bool)
+	h.mx.Lock()
+	for c := range h.connections {
+		if c.Identity != identity {
+			continue
+		}
+		for room := range c.rooms {
+			rooms[room] = true
+		}
+	}
+	h.mx.Unlock()
+	if h.users != nil {
+		for _, room := range h.users.Rooms(identity) {
+			rooms[room] = true
+		}
+	}
+	return rooms
+}
@@ -0,0 +1,143 @@
+package webchat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sessionTransport is a transport where the peer sends messages with separate
+// http requests, see ServeSend
+type sessionTransport interface {
+	Transport
+	deliver(m *Message, size int) error
+	owner() string
+}
+
+// inbox implements the receiving half of a session transport
+type inbox struct {
+	frameBytes
+	in        chan *Message
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// client identity of the request that opened the session, the session
+	// id alone does not prove it
+	identity string
+}
+
+func newInbox(identity string) inbox {
+	return inbox{
+		in:       make(chan *Message, 16),
+		done:     make(chan struct{}),
+		identity: identity,
+	}
+}
+
+func (b *inbox) owner() string {
+	return b.identity
+}
+
+func (b *inbox) ReadMessage() (*Message, error) {
+	select {
+	case m := <-b.in:
+		return m, nil
+	case <-b.done:
+		return nil, fmt.Errorf("session closed")
+	}
+}
+
+// deliver hands m, decoded from a frame of size bytes, to ReadMessage
+func (b *inbox) deliver(m *Message, size int) error {
+	select {
+	case b.in <- m:
+		b.read(size)
+		return nil
+	case <-b.done:
+		return fmt.Errorf("session closed")
+	case <-time.After(writeWait):
+		return fmt.Errorf("session busy")
+	}
+}
+
+func (b *inbox) Close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+	return nil
+}
+
+func newSessionId() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (h *Handler) addSession(t sessionTransport) (string, error) {
+	id, err := newSessionId()
+	if err != nil {
+		return "", err
+	}
+	h.mx.Lock()
+	h.sessions[id] = t
+	h.mx.Unlock()
+	return id, nil
+}
+
+func (h *Handler) removeSession(id string) {
+	h.mx.Lock()
+	delete(h.sessions, id)
+	h.mx.Unlock()
+}
+
+// getSession returns the session of r, given in the session query parameter.
+// r must present the client identity that opened the session.
+func (h *Handler) getSession(r *http.Request) sessionTransport {
+	h.mx.Lock()
+	t := h.sessions[r.URL.Query().Get("session")]
+	h.mx.Unlock()
+	if t == nil || t.owner() != clientIdentity(r) {
+		return nil
+	}
+	return t
+}
+
+// ServeSend accepts a json message POSTed by a sse or long poll client. The
+// session is given in the session query parameter.
+func (h *Handler) ServeSend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", 405)
+		return
+	}
+	if h.checkOrigin(r) == false {
+		http.Error(w, "Forbidden", 403)
+		return
+	}
+	t := h.getSession(r)
+	if t == nil {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxFrameSize))
+	if err != nil {
+		http.Error(w, "Request too large", 413)
+		return
+	}
+	m := &Message{}
+	err = m.FromJson(data)
+	if err != nil {
+		http.Error(w, "Bad request", 400)
+		return
+	}
+	err = t.deliver(m, len(data))
+	if err != nil {
+		http.Error(w, err.Error(), 503)
+		return
+	}
+	w.WriteHeader(204)
+}
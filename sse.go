@@ -0,0 +1,98 @@
+package webchat
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// sseTransport streams messages to the peer as server sent events. Messages
+// from the peer arrive through ServeSend.
+type sseTransport struct {
+	inbox
+	mx      sync.Mutex
+	closed  bool
+	w       http.ResponseWriter
+	flusher http.Flusher
+	remote  string
+}
+
+func (t *sseTransport) write(format string, args ...interface{}) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	if t.closed {
+		return fmt.Errorf("session closed")
+	}
+	n, err := fmt.Fprintf(t.w, format, args...)
+	t.wrote(n)
+	if err != nil {
+		return err
+	}
+	t.flusher.Flush()
+	return nil
+}
+
+func (t *sseTransport) WriteMessage(m *Message) error {
+	return t.write("data: %s\n\n", m.Json())
+}
+
+func (t *sseTransport) Ping() error {
+	return t.write(": ping\n\n")
+}
+
+// Close stops further writes. It waits for a write in progress so the
+// response writer is not used after the handler returns
+func (t *sseTransport) Close() error {
+	t.mx.Lock()
+	t.closed = true
+	t.mx.Unlock()
+	return t.inbox.Close()
+}
+
+func (t *sseTransport) RemoteAddr() string {
+	return t.remote
+}
+
+// ServeSSE streams messages to the peer as server sent events. The first
+// event is a "session" event carrying the session id to use with ServeSend.
+func (h *Handler) ServeSSE(w http.ResponseWriter, r *http.Request) {
+	if h.checkOrigin(r) == false {
+		http.Error(w, "Forbidden", 403)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if ok == false {
+		http.Error(w, "Streaming unsupported", 500)
+		return
+	}
+	identity := clientIdentity(r)
+	t := &sseTransport{
+		inbox:   newInbox(identity),
+		w:       w,
+		flusher: flusher,
+		remote:  r.RemoteAddr,
+	}
+	session, err := h.addSession(t)
+	if err != nil {
+		log.Printf("sse session: %s", err)
+		http.Error(w, "Internal error", 500)
+		return
+	}
+	defer h.removeSession(session)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(200)
+	t.write("event: session\ndata: {\"session\":%q}\n\n", session)
+
+	go func() {
+		select {
+		case <-r.Context().Done():
+			t.Close()
+		case <-t.done:
+		}
+	}()
+	h.ServeTransport(t, identity, identity)
+}
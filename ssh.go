@@ -0,0 +1,292 @@
+package webchat
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const sshPrompt = "> "
+
+// SSHConfig configures the ssh frontend
+type SSHConfig struct {
+	HostKey ssh.Signer
+
+	// AuthorizedKeys maps SHA256 key fingerprints to a display name. Other
+	// keys are refused, all of them when it is empty. The fingerprint becomes
+	// the connection Identity and the name can not be changed.
+	AuthorizedKeys map[string]string
+}
+
+// LoadHostKey reads a private host key, generating and saving an ed25519 key
+// if filename does not exist
+func LoadHostKey(filename string) (ssh.Signer, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		log.Printf("generating ssh host key %s", filename)
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		err = ioutil.WriteFile(filename, data, 0600)
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return ssh.ParsePrivateKey(data)
+}
+
+// LoadAuthorizedKeys reads an authorized_keys file. The key comment is used
+// as the display name, falling back to the fingerprint.
+func LoadAuthorizedKeys(filename string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]string)
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, comment, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", filename, err)
+		}
+		fingerprint := ssh.FingerprintSHA256(key)
+		if comment == "" {
+			comment = fingerprint
+		}
+		ret[fingerprint] = comment
+		data = rest
+	}
+	return ret, nil
+}
+
+func (config *SSHConfig) serverConfig() *ssh.ServerConfig {
+	sc := &ssh.ServerConfig{
+		PublicKeyCallback: func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			fingerprint := ssh.FingerprintSHA256(key)
+			name, ok := config.AuthorizedKeys[fingerprint]
+			if ok == false {
+				return nil, fmt.Errorf("unknown public key %s", fingerprint)
+			}
+			perms := &ssh.Permissions{
+				Extensions: map[string]string{
+					"fingerprint": fingerprint,
+					"name":        name,
+				},
+			}
+			return perms, nil
+		},
+	}
+	sc.AddHostKey(config.HostKey)
+	return sc
+}
+
+// ServeSSH accepts ssh connections on l. Users authenticate with a public key
+// and get a terminal chat ui speaking the line protocol. It returns when the
+// listener fails.
+func (h *Handler) ServeSSH(l net.Listener, config *SSHConfig) error {
+	sc := config.serverConfig()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go h.serveSSHConn(conn, sc)
+	}
+}
+
+func (h *Handler) serveSSHConn(conn net.Conn, sc *ssh.ServerConfig) {
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, sc)
+	if err != nil {
+		log.Printf("ssh handshake %s: %s", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	fingerprint := sconn.Permissions.Extensions["fingerprint"]
+	name := sconn.Permissions.Extensions["name"]
+	log.Printf("ssh connection from %s user:%s key:%s", conn.RemoteAddr(), name, fingerprint)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		ch, requests, err := nc.Accept()
+		if err != nil {
+			log.Printf("ssh accept: %s", err)
+			continue
+		}
+		term := newSSHTerminal(ch)
+		shell := make(chan bool, 1)
+		go term.handleRequests(requests, shell)
+		go func() {
+			if <-shell == false {
+				ch.Close()
+				return
+			}
+			t := newLineTransport(term, conn.RemoteAddr().String())
+			t.writeString(fmt.Sprintf("*** welcome %s, /help for help\n", name))
+			t.setNick(name)
+			h.ServeTransport(t, fingerprint, name)
+			sconn.Close()
+		}()
+	}
+}
+
+// sshTerminal is a minimal line editor over an ssh channel. Read returns
+// complete lines, Write prints above the input line and redraws it.
+type sshTerminal struct {
+	ch  ssh.Channel
+	mx  sync.Mutex
+	buf []rune
+
+	// completed lines not yet read
+	lines bytes.Buffer
+	raw   [256]byte
+	esc   int
+}
+
+func newSSHTerminal(ch ssh.Channel) *sshTerminal {
+	return &sshTerminal{ch: ch}
+}
+
+func (t *sshTerminal) handleRequests(requests <-chan *ssh.Request, shell chan bool) {
+	started := false
+	for req := range requests {
+		ok := false
+		switch req.Type {
+		case "pty-req", "window-change", "env":
+			ok = true
+		case "shell":
+			ok = started == false
+			if ok {
+				started = true
+				shell <- true
+			}
+		}
+		if req.WantReply {
+			req.Reply(ok, nil)
+		}
+	}
+	if started == false {
+		shell <- false
+	}
+}
+
+func (t *sshTerminal) Read(p []byte) (int, error) {
+	for {
+		t.mx.Lock()
+		if t.lines.Len() > 0 {
+			n, _ := t.lines.Read(p)
+			t.mx.Unlock()
+			return n, nil
+		}
+		t.mx.Unlock()
+
+		n, err := t.ch.Read(t.raw[:])
+		if err != nil {
+			return 0, err
+		}
+		err = t.input(t.raw[:n])
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// input applies raw key presses to the line being edited
+func (t *sshTerminal) input(data []byte) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	for _, r := range string(data) {
+		if t.esc > 0 {
+			// skip escape sequences such as arrow keys
+			if t.esc == 1 && (r == '[' || r == 'O') {
+				t.esc = 2
+			} else if t.esc == 1 || r >= '@' && r <= '~' {
+				t.esc = 0
+			}
+			continue
+		}
+		switch {
+		case r == 0x1b:
+			t.esc = 1
+		case r == '\r' || r == '\n':
+			if len(t.buf) == 0 {
+				continue
+			}
+			t.lines.WriteString(string(t.buf))
+			t.lines.WriteByte('\n')
+			t.buf = t.buf[:0]
+			t.ch.Write([]byte("\r\n" + sshPrompt))
+		case r == 0x7f || r == 0x08:
+			if len(t.buf) > 0 {
+				t.buf = t.buf[:len(t.buf)-1]
+				t.ch.Write([]byte("\b \b"))
+			}
+		case r == 0x03 || r == 0x04 && len(t.buf) == 0:
+			return io.EOF
+		case r == 0x15:
+			t.buf = t.buf[:0]
+			t.ch.Write([]byte("\r\x1b[K" + sshPrompt))
+		case r >= ' ':
+			// the limit is in bytes like the line transport's
+			if len(string(t.buf))+utf8.RuneLen(r) <= maxMessageSize {
+				t.buf = append(t.buf, r)
+				t.ch.Write([]byte(string(r)))
+			}
+		}
+	}
+	return nil
+}
+
+// Write prints text above the prompt, control characters other users sent
+// are removed
+func (t *sshTerminal) Write(p []byte) (int, error) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	var out bytes.Buffer
+	out.WriteString("\r\x1b[K")
+	if bytes.HasPrefix(p, []byte("\a")) {
+		// the bell of a highlight
+		out.WriteString("\a")
+	}
+	text := StripControl(strings.TrimRight(string(p), "\n"))
+	out.WriteString(strings.Replace(text, "\n", "\r\n", -1))
+	out.WriteString("\r\n" + sshPrompt + string(t.buf))
+	_, err := t.ch.Write(out.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *sshTerminal) Close() error {
+	status := make([]byte, 4)
+	binary.BigEndian.PutUint32(status, 0)
+	t.ch.SendRequest("exit-status", false, status)
+	return t.ch.Close()
+}
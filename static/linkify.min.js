@@ -1 +0,0 @@
-!function(){"use strict";function t(t,n){if("function"!=typeof n&&null!==n)throw new TypeError("Super expression must either be null or a function, not "+typeof n);t.prototype=Object.create(n&&n.prototype,{constructor:{value:t,enumerable:!1,writable:!0,configurable:!0}}),n&&(Object.setPrototypeOf?Object.setPrototypeOf(t,n):t.__proto__=n)}function n(t,n){if(!(t instanceof n))throw new TypeError("Cannot call a class as a function")}function e(t,n,e,o){for(var r=0,i=t.length,a=[],s=void 0;i>r&&(s=n.next(t[r]));)n=s,r++;if(r>=i)return[];for(;i-1>r;)s=new g(o),a.push(s),n.on(t[r],s),n=s,r++;return s=new g(e),a.push(s),n.on(t[i-1],s),a}function o(t,n){if("function"!=typeof n&&null!==n)throw new TypeError("Super expression must either be null or a function, not "+typeof n);t.prototype=Object.create(n&&n.prototype,{constructor:{value:t,enumerable:!1,writable:!0,configurable:!0}}),n&&(Object.setPrototypeOf?Object.setPrototypeOf(t,n):t.__proto__=n)}function r(t,n){if(!(t instanceof n))throw new TypeError("Cannot call a class as a function")}function i(t){return t instanceof b||t instanceof j}function a(t){return t}function s(t){return!0}function u(t,n){return"url"===n?"_blank":null}function c(t){return t=t||{},{attributes:t.linkAttributes||null,defaultProtocol:t.defaultProtocol||"http",events:t.events||null,format:t.format||a,validate:t.validate||s,formatHref:t.formatHref||a,newLine:t.newLine||!1,nl2br:!!t.newLine||t.nl2br||!1,tagName:t.tagName||"a",target:t.target||u,linkClass:t.linkClass||"linkified"}}function l(t){for(var n=arguments.length,e=Array(n>1?n-1:0),o=1;n>o;o++)e[o-1]=arguments[o];return"function"==typeof t?t.apply(void 0,e):t}function p(t){if(t&&t.__esModule)return t;var n={};if(null!=t)for(var e in t)Object.prototype.hasOwnProperty.call(t,e)&&(n[e]=t[e]);return n["default"]=t,n}var f={__esModule:!0},h=function(){function t(e){n(this,t),this.j=[],this.T=e||null}return t.prototype.on=function(t,n){if(t instanceof Array)for(var e=0;e<t.length;e++)this.j.push([t[e],n]);else this.j.push([t,n])},t.prototype.next=function(t){for(var n=0;n<this.j.length;n++){var e=this.j[n],o=e[1];if(this.test(t,e[0]))return o}return!1},t.prototype.accepts=function(){return!!this.T},t.prototype.test=function(t,n){return t===n},t.prototype.emit=function(){return this.T},t}(),g=function(e){function o(){n(this,o),e.apply(this,arguments)}return t(o,e),o.prototype.test=function(t,n){return t===n||n instanceof RegExp&&n.test(t)},o}(h),m=function(e){function o(){n(this,o),e.apply(this,arguments)}return t(o,e),o.prototype.test=function(t,n){return t instanceof n},o}(h);f.CharacterState=g,f.TokenState=m,f.stateify=e;var y={__esModule:!0},d=function(){function t(n){r(this,t),this.v=n}return t.prototype.toString=function(){return this.v+""},t}(),b=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),v=function(t){function n(){r(this,n),t.call(this,"@")}return o(n,t),n}(d),x=function(t){function n(){r(this,n),t.call(this,":")}return o(n,t),n}(d),k=function(t){function n(){r(this,n),t.call(this,".")}return o(n,t),n}(d),w=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),O=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),E=function(t){function n(){r(this,n),t.call(this,"\n")}return o(n,t),n}(d),L=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),S=function(t){function n(){r(this,n),t.call(this,"+")}return o(n,t),n}(d),A=function(t){function n(){r(this,n),t.call(this,"#")}return o(n,t),n}(d),C=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),T=function(t){function n(){r(this,n),t.call(this,"?")}return o(n,t),n}(d),N=function(t){function n(){r(this,n),t.call(this,"/")}return o(n,t),n}(d),P=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),j=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),z=function(t){function n(){r(this,n),t.apply(this,arguments)}return o(n,t),n}(d),R=function(t){function n(){r(this,n),t.call(this,"{")}return o(n,t),n}(d),_=function(t){function n(){r(this,n),t.call(this,"[")}return o(n,t),n}(d),U=function(t){function n(){r(this,n),t.call(this,"(")}return o(n,t),n}(d),M=function(t){function n(){r(this,n),t.call(this,"}")}return o(n,t),n}(d),B=function(t){function n(){r(this,n),t.call(this,"]")}return o(n,t),n}(d),D=function(t){function n(){r(this,n),t.call(this,")")}return o(n,t),n}(d),H={Base:d,DOMAIN:b,AT:v,COLON:x,DOT:k,PUNCTUATION:w,LOCALHOST:O,NL:E,NUM:L,PLUS:S,POUND:A,QUERY:T,PROTOCOL:C,SLASH:N,SYM:P,TLD:j,WS:z,OPENBRACE:R,OPENBRACKET:_,OPENPAREN:U,CLOSEBRACE:M,CLOSEBRACKET:B,CLOSEPAREN:D},q=function(){function t(n){r(this,t),this.v=n,this.type="token",this.isLink=!1}return t.prototype.toString=function(){for(var t=[],n=0;n<this.v.length;n++)t.push(this.v[n].toString());return t.join("")},t.prototype.toHref=function(){return this.toString()},t.prototype.toObject=function(){return{type:this.type,value:this.toString(),href:this.toHref(0>=arguments.length||void 0===arguments[0]?"http":arguments[0])}},t}(),I=function(t){function n(e){r(this,n),t.call(this,e),this.type="email",this.isLink=!0}return o(n,t),n.prototype.toHref=function(){return"mailto:"+this.toString()},n}(q),K=function(t){function n(e){r(this,n),t.call(this,e),this.type="text"}return o(n,t),n}(q),Y=function(t){function n(e){r(this,n),t.call(this,e),this.type="nl"}return o(n,t),n}(q),Q=function(t){function n(e){r(this,n),t.call(this,e),this.type="url",this.isLink=!0}return o(n,t),n.prototype.toHref=function(){for(var t=0>=arguments.length||void 0===arguments[0]?"http":arguments[0],n=!1,e=!1,o=this.v,r=[],a=0;o[a]instanceof C;)n=!0,r.push(o[a].toString().toLowerCase()),a++;for(;o[a]instanceof N;)e=!0,r.push(o[a].toString()),a++;for(;i(o[a]);)r.push(o[a].toString().toLowerCase()),a++;for(;a<o.length;a++)r.push(o[a].toString());return r=r.join(""),n||e||(r=t+"://"+r),r},n.prototype.hasProtocol=function(){return this.v[0]instanceof C},n}(q),W={Base:q,EMAIL:I,NL:Y,TEXT:K,URL:Q};y.text=H,y.multi=W;var X={__esModule:!0},Z=y,F=f,G=function(t){return new F.TokenState(t)},J=Z.text.DOMAIN,V=Z.text.AT,$=Z.text.COLON,tt=Z.text.DOT,nt=Z.text.PUNCTUATION,et=Z.text.LOCALHOST,ot=Z.text.NL,rt=Z.text.NUM,it=Z.text.PLUS,at=Z.text.POUND,st=Z.text.PROTOCOL,ut=Z.text.QUERY,ct=Z.text.SLASH,lt=Z.text.SYM,pt=Z.text.TLD,ft=Z.text.OPENBRACE,ht=Z.text.OPENBRACKET,gt=Z.text.OPENPAREN,mt=Z.text.CLOSEBRACE,yt=Z.text.CLOSEBRACKET,dt=Z.text.CLOSEPAREN,bt=Z.multi.EMAIL,vt=Z.multi.NL,xt=Z.multi.TEXT,kt=Z.multi.URL,wt=G(),Ot=G(),Et=G(),Lt=G(),St=G(),At=G(),Ct=G(kt),Tt=G(),Nt=G(kt),Pt=G(),jt=G(),zt=G(kt),Rt=G(),_t=G(kt),Ut=G(kt),Mt=G(),Bt=G(),Dt=G(),Ht=G(),qt=G(kt),It=G(kt),Kt=G(kt),Yt=G(),Qt=G(),Wt=G(),Xt=G(),Zt=G(),Ft=G(bt),Gt=G(),Jt=G(bt),Vt=G(),$t=G(),tn=G(),nn=G(vt);wt.on(ot,nn),wt.on(st,Ot),wt.on(ct,Et),Ot.on(ct,Et),Et.on(ct,Lt),wt.on(pt,St),wt.on(J,St),wt.on(et,Ct),wt.on(rt,St),Lt.on(pt,Pt),Lt.on(J,Pt),Lt.on(rt,Pt),Lt.on(et,zt),St.on(tt,At),Pt.on(tt,jt),Xt.on(tt,Zt),At.on(pt,Ct),At.on(J,St),At.on(rt,St),At.on(et,St),jt.on(pt,zt),jt.on(J,Pt),jt.on(rt,Pt),jt.on(et,Pt),Zt.on(pt,Ft),Zt.on(J,Xt),Zt.on(rt,Xt),Zt.on(et,Xt),Ct.on(tt,At),zt.on(tt,jt),Ft.on(tt,Zt),Ct.on($,Tt),Ct.on(ct,Ut),Tt.on(rt,Nt),Nt.on(ct,Ut),zt.on($,Rt),zt.on(ct,Ut),Rt.on(rt,_t),_t.on(ct,Ut),Ft.on($,Gt),Gt.on(rt,Jt);var en=[J,V,et,rt,it,at,st,ct,pt],on=[$,tt,ut,nt,mt,yt,dt,ft,ht,gt,lt];Ut.on(ft,Bt),Ut.on(ht,Dt),Ut.on(gt,Ht),Mt.on(ft,Bt),Mt.on(ht,Dt),Mt.on(gt,Ht),Bt.on(mt,Ut),Dt.on(yt,Ut),Ht.on(dt,Ut),qt.on(mt,Ut),It.on(yt,Ut),Kt.on(dt,Ut),Yt.on(mt,Ut),Qt.on(yt,Ut),Wt.on(dt,Ut),Bt.on(en,qt),Dt.on(en,It),Ht.on(en,Kt),Bt.on(on,Yt),Dt.on(on,Qt),Ht.on(on,Wt),qt.on(en,qt),It.on(en,It),Kt.on(en,Kt),qt.on(on,qt),It.on(on,It),Kt.on(on,Kt),Yt.on(en,qt),Qt.on(en,It),Wt.on(en,Kt),Yt.on(on,Yt),Qt.on(on,Qt),Wt.on(on,Wt),Ut.on(en,Ut),Mt.on(en,Ut),Ut.on(on,Mt),Mt.on(on,Mt);var rn=[J,rt,it,at,ut,lt,pt];St.on(rn,Vt),St.on(V,$t),At.on(rn,Vt),Ct.on(rn,Vt),Ct.on(V,$t),Vt.on(rn,Vt),Vt.on(V,$t),Vt.on(tt,tn),tn.on(rn,Vt),$t.on(pt,Xt),$t.on(J,Xt),$t.on(et,Ft);var an=function(t){for(var n=t.length,e=0,o=[],r=[];n>e;){for(var i=wt,a=null,s=null,u=0,c=null,l=-1;n>e&&!(a=i.next(t[e]));)r.push(t[e++]);for(;n>e&&(s=a||i.next(t[e]));)a=null,i=s,i.accepts()?(l=0,c=i):l>=0&&l++,e++,u++;if(0>l)for(u=e-u;e>u;u++)r.push(t[u]);else 0<r.length&&(o.push(new xt(r)),r=[]),e-=l,u-=l,i=c.emit(),o.push(new i(t.slice(e-u,e)))}return 0<r.length&&o.push(new xt(r)),o},sn=Z.multi,un=wt;X.State=F.TokenState,X.TOKENS=sn,X.run=an,X.start=un;var cn={__esModule:!0},ln=y,pn=f,fn="abogado ac academy accountants active actor ad adult ae aero af ag agency ai airforce al allfinanz alsace am an android ao aq aquarelle ar archi army arpa as asia associates at attorney au auction audio autos aw ax axa az ba band bar bargains bayern bb bd be beer berlin best bf bg bh bi bid bike bio biz bj black blackfriday bloomberg blue bm bmw bn bnpparibas bo boo boutique br brussels bs bt budapest build builders business buzz bv bw by bz bzh ca cab cal camera camp cancerresearch capetown capital caravan cards care career careers casa cash cat catering cc cd center ceo cern cf cg ch channel cheap christmas chrome church ci citic city ck cl claims cleaning click clinic clothing club cm cn co coach codes coffee college cologne com community company computer condos construction consulting contractors cooking cool coop country cr credit creditcard cricket crs cruises cu cuisinella cv cw cx cy cymru cz dad dance dating day de deals degree delivery democrat dental dentist desi diamonds diet digital direct directory discount dj dk dm dnp do domains durban dvag dz eat ec edu education ee eg email emerck energy engineer engineering enterprises equipment er es esq estate et eu eurovision eus events everbank exchange expert exposed fail farm fashion feedback fi finance financial firmdale fish fishing fitness fj fk flights florist flsmidth fly fm fo foo forsale foundation fr frl frogans fund furniture futbol ga gal gallery gb gbiz gd ge gent gf gg gh gi gift gifts gives gl glass gle global globo gm gmail gmo gmx gn google gop gov gp gq gr graphics gratis green gripe gs gt gu guide guitars guru gw gy hamburg haus healthcare help here hiphop hiv hk hm hn holdings holiday homes horse host hosting house how hr ht hu ibm id ie il im immo immobilien in industries info ing ink institute insure int international investments io iq ir irish is it je jetzt jm jo jobs joburg jp juegos kaufen ke kg kh ki kim kitchen kiwi km kn koeln kp kr krd kred kw ky kz la lacaixa land latrobe lawyer lb lc lds lease legal lgbt li life lighting limited limo link lk loans local london lotto lr ls lt ltda lu luxe luxury lv ly ma madrid maison management mango market marketing mc md me media meet melbourne meme memorial menu mg mh miami mil mini mk ml mm mn mo mobi moda moe monash money mormon mortgage moscow motorcycles mov mp mq mr ms mt mu museum mv mw mx my mz na nagoya name navy nc ne net network neustar new nexus nf ng ngo nhk ni ninja nl no np nr nra nrw nu nyc nz okinawa om ong onl ooo org organic otsuka ovh pa paris partners parts party pe pf pg ph pharmacy photo photography photos physio pics pictures pink pizza pk pl place plumbing pm pn pohl poker porn post pr praxi press pro prod productions prof properties property ps pt pub pw py qa qpon quebec re realtor recipes red rehab reise reisen reit ren rentals repair report republican rest restaurant reviews rich rio rip ro rocks rodeo rs rsvp ru ruhr rw ryukyu sa saarland sarl sb sc sca scb schmidt schule science scot sd se services sexy sg sh shiksha shoes si singles sj sk sl sm sn so social software sohu solar solutions soy space spiegel sr st su supplies supply support surf surgery suzuki sv sx sy sydney systems sz taipei tatar tattoo tax tc td technology tel tf tg th tienda tips tirol tj tk tl tm tn to today tokyo tools top town toys tp tr trade training travel trust tt tui tv tw tz ua ug uk university uno uol us uy uz va vacations vc ve vegas ventures versicherung vet vg vi viajes villas vision vlaanderen vn vodka vote voting voto voyage vu wales wang watch webcam website wed wedding wf whoswho wien wiki williamhill wme work works world ws wtc wtf xxx xyz yachts yandex ye yoga yokohama youtube yt za zip zm zone zw".split(" "),hn=/[0-9]/,gn=/[a-z0-9]/,mn=":",yn=[],dn=function(t){return new pn.CharacterState(t)},bn=ln.text.DOMAIN,vn=ln.text.LOCALHOST,xn=ln.text.NUM,kn=ln.text.PROTOCOL,wn=ln.text.TLD,On=ln.text.WS,En=dn(),Ln=dn(xn),Sn=dn(bn),An=dn(),Cn=dn(On);En.on("@",dn(ln.text.AT)),En.on(".",dn(ln.text.DOT)),En.on("+",dn(ln.text.PLUS)),En.on("#",dn(ln.text.POUND)),En.on("?",dn(ln.text.QUERY)),En.on("/",dn(ln.text.SLASH)),En.on(mn,dn(ln.text.COLON)),En.on("{",dn(ln.text.OPENBRACE)),En.on("[",dn(ln.text.OPENBRACKET)),En.on("(",dn(ln.text.OPENPAREN)),En.on("}",dn(ln.text.CLOSEBRACE)),En.on("]",dn(ln.text.CLOSEBRACKET)),En.on(")",dn(ln.text.CLOSEPAREN)),En.on(/[,;!]/,dn(ln.text.PUNCTUATION)),En.on(/\n/,dn(ln.text.NL)),En.on(/\s/,Cn),Cn.on(/[^\S\n]/,Cn);for(var Tn=0;Tn<fn.length;Tn++){var Nn=pn.stateify(fn[Tn],En,wn,bn);yn.push.apply(yn,Nn)}var Pn=pn.stateify("file",En,bn,bn),jn=pn.stateify("ftp",En,bn,bn),zn=pn.stateify("http",En,bn,bn);yn.push.apply(yn,Pn),yn.push.apply(yn,jn),yn.push.apply(yn,zn);var Rn=Pn.pop(),_n=jn.pop(),Un=zn.pop(),Mn=dn(bn),Bn=dn(kn);_n.on("s",Mn),Un.on("s",Mn),yn.push(Mn),Rn.on(mn,Bn),_n.on(mn,Bn),Un.on(mn,Bn),Mn.on(mn,Bn);var Dn=pn.stateify("localhost",En,vn,bn);for(yn.push.apply(yn,Dn),En.on(hn,Ln),Ln.on("-",An),Ln.on(hn,Ln),Ln.on(gn,Sn),Sn.on("-",An),Sn.on(gn,Sn),Tn=0;Tn<yn.length;Tn++)yn[Tn].on("-",An),yn[Tn].on(gn,Sn);An.on("-",An),An.on(hn,Sn),An.on(gn,Sn),En.on(/./,dn(ln.text.SYM));var Hn=function(t){for(var n=t.replace(/[A-Z]/g,function(t){return t.toLowerCase()}),e=t.length,o=[],r=0;e>r;){for(var i=En,a=null,s=0,u=null,c=-1;e>r&&(a=i.next(n[r]));)i=a,i.accepts()?(c=0,u=i):c>=0&&c++,s++,r++;0>c||(r-=c,s-=c,i=u.emit(),o.push(new i(t.substr(r-s,s))))}return o},qn=En;cn.State=pn.CharacterState,cn.TOKENS=ln.text,cn.run=Hn,cn.start=qn;var In={__esModule:!0};In.normalize=c,In.resolve=l;var Kn={__esModule:!0},Yn=In,Qn=p(Yn),Wn=cn,Xn=p(Wn),Zn=X,Fn=p(Zn);Array.isArray||(Array.isArray=function(t){return"[object Array]"===Object.prototype.toString.call(t)});var Gn=function(t){return Fn.run(Xn.run(t))},Jn=function(t){for(var n=1>=arguments.length||void 0===arguments[1]?null:arguments[1],e=Gn(t),o=[],r=0;r<e.length;r++)!e[r].isLink||n&&e[r].type!==n||o.push(e[r].toObject());return o},Vn=function(t){var n=1>=arguments.length||void 0===arguments[1]?null:arguments[1],e=Gn(t);return 1===e.length&&e[0].isLink&&(!n||e[0].type===n)};Kn.find=Jn,Kn.options=Qn,Kn.parser=Fn,Kn.scanner=Xn,Kn.test=Vn,Kn.tokenize=Gn,window.linkify=Kn}();
\ No newline at end of file
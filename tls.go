@@ -0,0 +1,145 @@
+package webchat
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate loaded from disk and reloads it when the
+// files change, ie when a mounted kubernetes secret is updated
+type CertReloader struct {
+	mx       sync.Mutex
+	certFile string
+	keyFile  string
+	cert     *tls.Certificate
+	modTime  time.Time
+}
+
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	err := r.reload()
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, filename := range []string{r.certFile, r.keyFile} {
+		st, err := os.Stat(filename)
+		if err != nil {
+			return latest, err
+		}
+		if st.ModTime().After(latest) {
+			latest = st.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *CertReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mx.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mx.Unlock()
+	return nil
+}
+
+// Watch polls the certificate files and reloads them when modified. It does
+// not return.
+func (r *CertReloader) Watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		modTime, err := r.latestModTime()
+		if err != nil {
+			log.Printf("certificate stat: %s", err)
+			continue
+		}
+		r.mx.Lock()
+		changed := modTime.Equal(r.modTime) == false
+		r.mx.Unlock()
+		if changed == false {
+			continue
+		}
+		err = r.reload()
+		if err != nil {
+			// keep serving the old certificate, the secret may be mid update
+			log.Printf("certificate reload: %s", err)
+			continue
+		}
+		log.Printf("reloaded certificate %s", r.certFile)
+	}
+}
+
+// GetCertificate is suitable for tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.cert, nil
+}
+
+// NewTLSConfig returns a server config backed by reloader. If clientCAFile is
+// given, client certificates signed by it are verified when presented but not
+// required, so browsers can still connect without one.
+func NewTLSConfig(reloader *CertReloader, clientCAFile string) (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if clientCAFile == "" {
+		return config, nil
+	}
+	data, err := ioutil.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if pool.AppendCertsFromPEM(data) == false {
+		return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+	}
+	config.ClientCAs = pool
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	return config, nil
+}
+
+// RedirectHTTPS redirects plain http requests to https on httpsPort
+func RedirectHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = host + ":" + httpsPort
+		}
+		target := "https://" + host + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+}
+
+// clientIdentity returns the common name of a verified client certificate
+func clientIdentity(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
@@ -0,0 +1,161 @@
+package webchat
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport moves messages between a Connection and its peer
+type Transport interface {
+	// ReadMessage blocks until the next message arrives from the peer
+	ReadMessage() (*Message, error)
+
+	// WriteMessage delivers a message to the peer
+	WriteMessage(m *Message) error
+
+	// Ping is called every pingPeriod to keep the peer alive. An error closes
+	// the connection
+	Ping() error
+
+	Close() error
+
+	RemoteAddr() string
+}
+
+// byteCounter transports count the bytes of the frames they read from and
+// write to the peer, after encoding
+type byteCounter interface {
+	counted() (in, out int64)
+}
+
+// frameBytes implements byteCounter for the transports that embed it
+type frameBytes struct {
+	in  int64
+	out int64
+}
+
+func (b *frameBytes) read(n int) {
+	atomic.AddInt64(&b.in, int64(n))
+}
+
+func (b *frameBytes) wrote(n int) {
+	atomic.AddInt64(&b.out, int64(n))
+}
+
+func (b *frameBytes) counted() (int64, int64) {
+	return atomic.LoadInt64(&b.in), atomic.LoadInt64(&b.out)
+}
+
+// connectionAware transports are told the connection they serve before it is
+// registered
+type connectionAware interface {
+	attach(c *Connection)
+}
+
+// wsTransport is the websocket transport
+type wsTransport struct {
+	frameBytes
+
+	// gorilla supports a single concurrent writer, Close is called by both
+	// pumps while the write pump may be writing
+	mx    sync.Mutex
+	ws    *websocket.Conn
+	codec Codec
+
+	// set when permessage-deflate was negotiated, messages shorter than
+	// threshold are sent uncompressed
+	compressed bool
+	threshold  int
+	wire       *countingConn
+	stats      *compressionStats
+}
+
+func newWsTransport(ws *websocket.Conn, codec Codec) *wsTransport {
+	ws.SetReadLimit(maxFrameSize)
+	ws.SetReadDeadline(time.Now().Add(pongWait))
+	ws.SetPongHandler(func(string) error { ws.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+	return &wsTransport{ws: ws, codec: codec}
+}
+
+// compress compresses the messages of at least threshold bytes, counting
+// the bytes written to wire in stats
+func (t *wsTransport) compress(threshold int, wire *countingConn, stats *compressionStats) {
+	t.compressed = true
+	t.threshold = threshold
+	t.wire = wire
+	t.stats = stats
+	atomic.AddInt64(&stats.connections, 1)
+}
+
+func (t *wsTransport) ReadMessage() (*Message, error) {
+	for {
+		_, data, err := t.ws.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		t.read(len(data))
+		m := &Message{}
+		err = t.codec.Unmarshal(data, m)
+		if err != nil {
+			log.Printf("ERROR: %s [ %q ]: %s", t.codec.Name(), data, err)
+			continue
+		}
+		return m, nil
+	}
+}
+
+// write writes a message with the given message type and payload.
+func (t *wsTransport) write(mt int, payload []byte) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return t.ws.WriteMessage(mt, payload)
+}
+
+func (t *wsTransport) WriteMessage(m *Message) error {
+	data, err := t.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+	mt := websocket.TextMessage
+	if t.codec.Binary() {
+		mt = websocket.BinaryMessage
+	}
+	if t.compressed == false {
+		err = t.write(mt, data)
+		if err == nil {
+			t.wrote(len(data))
+		}
+		return err
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.ws.EnableWriteCompression(len(data) >= t.threshold)
+	written := atomic.LoadInt64(&t.wire.written)
+	t.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	err = t.ws.WriteMessage(mt, data)
+	if err == nil {
+		t.wrote(len(data))
+	}
+	atomic.AddInt64(&t.stats.messages, int64(len(data)))
+	atomic.AddInt64(&t.stats.wire, atomic.LoadInt64(&t.wire.written)-written)
+	return err
+}
+
+func (t *wsTransport) Ping() error {
+	return t.write(websocket.PingMessage, []byte{})
+}
+
+func (t *wsTransport) Close() error {
+	t.write(websocket.CloseMessage, []byte{})
+	return t.ws.Close()
+}
+
+func (t *wsTransport) RemoteAddr() string {
+	return t.ws.RemoteAddr().String()
+}
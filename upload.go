@@ -0,0 +1,335 @@
+package webchat
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"image"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// most attachments on a message
+	maxAttachments = 10
+
+	// longest attachment name kept
+	maxAttachmentName = 255
+
+	// path attachments are served under, relative to the api
+	uploadsPath = "/api/uploads/"
+
+	// uploads not attached to a message within this time are deleted
+	uploadTTL = time.Hour
+
+	// uploads received at once, each is held in memory
+	maxConcurrentUploads = 8
+)
+
+// Attachment is a file uploaded with Uploads and attached to a message
+type Attachment struct {
+	URL  string `json:"url"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+	Type string `json:"type"`
+
+	// Thumbnail of an image served by the media endpoint, and the size of
+	// the image in pixels
+	Thumbnail string `json:"thumbnail,omitempty"`
+	Width     int    `json:"width,omitempty"`
+	Height    int    `json:"height,omitempty"`
+}
+
+// UploadForm documents the multipart form of an upload
+type UploadForm struct {
+	File []byte `json:"file"`
+}
+
+// Uploads accepts files from identified users, keeps them in a BlobStore and
+// resolves the attachments of messages to the files uploaded by their author.
+// Files not attached within uploadTTL are deleted with the next upload.
+type Uploads struct {
+	store BlobStore
+
+	// MaxSize of an upload in bytes
+	MaxSize int64
+
+	// Types are the allowed media types, sniffed from the content. A type
+	// ending in / allows all its subtypes, ie image/
+	Types []string
+
+	// BaseURL is prepended to attachment urls, ie https://chat.example.com
+	BaseURL string
+
+	// images and thumbnails recently served by the media endpoint
+	cache *mediaCache
+
+	// slots of the uploads being received and of the images being decoded
+	receiving chan struct{}
+	decoding  chan struct{}
+
+	mx sync.Mutex
+
+	// uploads not attached to a message yet, by key
+	unattached map[string]*upload
+}
+
+type upload struct {
+	author     string
+	attachment *Attachment
+	created    time.Time
+}
+
+func NewUploads(store BlobStore, maxSize int64, types []string) *Uploads {
+	return &Uploads{
+		store:      store,
+		MaxSize:    maxSize,
+		Types:      types,
+		cache:      newMediaCache(mediaCacheSize),
+		receiving:  make(chan struct{}, maxConcurrentUploads),
+		decoding:   make(chan struct{}, maxDecoders),
+		unattached: make(map[string]*upload),
+	}
+}
+
+// SetUploads enables attachments. It must be called before Start.
+func (h *Hub) SetUploads(u *Uploads) {
+	h.uploads = u
+}
+
+// allowed reports whether the media type of contentType may be uploaded
+func (u *Uploads) allowed(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range u.Types {
+		if mediaType == t || (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve replaces the attachments of a message by author with the uploads
+// they point at, dropping any that author did not upload. Each upload can be
+// attached once.
+func (u *Uploads) resolve(author string, attachments []*Attachment) []*Attachment {
+	if u == nil || author == "" || len(attachments) == 0 {
+		return nil
+	}
+	if len(attachments) > maxAttachments {
+		attachments = attachments[:maxAttachments]
+	}
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	ret := make([]*Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		if a == nil {
+			continue
+		}
+		key := path.Base(a.URL)
+		up, ok := u.unattached[key]
+		if ok == false || up.author != author {
+			continue
+		}
+		delete(u.unattached, key)
+		ret = append(ret, up.attachment)
+	}
+	if len(ret) == 0 {
+		return nil
+	}
+	return ret
+}
+
+// expire forgets the uploads that were not attached within uploadTTL and
+// deletes their blobs
+func (u *Uploads) expire(now time.Time) {
+	expired := make(map[string]*upload)
+	u.mx.Lock()
+	for key, up := range u.unattached {
+		if now.Sub(up.created) > uploadTTL {
+			expired[key] = up
+			delete(u.unattached, key)
+		}
+	}
+	u.mx.Unlock()
+	for key, up := range expired {
+		err := u.store.Delete(key)
+		if err == nil && up.attachment.Thumbnail != "" {
+			err = u.store.Delete(key + "-thumb")
+		}
+		if err != nil {
+			log.Printf("expire upload %s: %s", key, err)
+		}
+	}
+}
+
+// newKey returns a random blob key
+func newKey() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// receive reads the file part of an upload form, failing the request when it
+// is missing or too large
+func (u *Uploads) receive(w http.ResponseWriter, r *http.Request) (string, []byte, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, u.MaxSize+1<<20)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Bad request: "+err.Error(), 400)
+		return "", nil, false
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			http.Error(w, "a file is required", 400)
+			return "", nil, false
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(part, u.MaxSize+1))
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), 400)
+			return "", nil, false
+		}
+		if int64(len(data)) > u.MaxSize {
+			http.Error(w, "file too large", 413)
+			return "", nil, false
+		}
+		name := path.Base(strings.Replace(part.FileName(), `\`, "/", -1))
+		if name == "." || name == "/" {
+			name = "file"
+		}
+		if len(name) > maxAttachmentName {
+			name = name[:maxAttachmentName]
+		}
+		return name, data, true
+	}
+}
+
+// POST /api/uploads stores a file for the client certificate identity
+func (a *API) postUpload(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	u := a.hub.uploads
+	if u == nil {
+		http.Error(w, "uploads are disabled", 501)
+		return
+	}
+	identity, ok := a.identity(w, r)
+	if ok == false {
+		return
+	}
+	u.expire(time.Now())
+	select {
+	case u.receiving <- struct{}{}:
+		defer func() { <-u.receiving }()
+	default:
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "too many uploads in progress", 503)
+		return
+	}
+	name, data, ok := u.receive(w, r)
+	if ok == false {
+		return
+	}
+	contentType := http.DetectContentType(data)
+	if u.allowed(contentType) == false || strippable(contentType) == false {
+		http.Error(w, "file type "+contentType+" is not allowed", 415)
+		return
+	}
+	data, err := stripMetadata(contentType, data)
+	if err != nil {
+		http.Error(w, "invalid image: "+err.Error(), 400)
+		return
+	}
+	key, err := newKey()
+	if err == nil {
+		err = u.store.Put(key, contentType, bytes.NewReader(data), int64(len(data)))
+	}
+	if err != nil {
+		log.Printf("upload %s: %s", name, err)
+		http.Error(w, "upload failed", 500)
+		return
+	}
+	attachment := &Attachment{
+		URL:  u.BaseURL + uploadsPath + key,
+		Name: name,
+		Size: int64(len(data)),
+		Type: contentType,
+	}
+	if strings.HasPrefix(contentType, "image/") {
+		u.addThumbnail(key, data, attachment)
+	}
+	u.mx.Lock()
+	u.unattached[key] = &upload{author: IdentityAuthor(identity), attachment: attachment, created: time.Now()}
+	u.mx.Unlock()
+	log.Printf("upload %s %s %d bytes by %s", key, contentType, len(data), identity)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(201)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// addThumbnail stores a thumbnail of the image uploaded under key. Images
+// that can not be decoded or are too large are attached without one.
+func (u *Uploads) addThumbnail(key string, data []byte, attachment *Attachment) {
+	thumb, contentType, size, err := u.thumbnail(data)
+	attachment.Width, attachment.Height = size.X, size.Y
+	if err == nil {
+		err = u.store.Put(key+"-thumb", contentType, bytes.NewReader(thumb), int64(len(thumb)))
+	}
+	if err != nil {
+		log.Printf("thumbnail %s: %s", key, err)
+		return
+	}
+	attachment.Thumbnail = u.BaseURL + mediaPath + key + "-thumb"
+}
+
+// thumbnail renders a thumbnail of an image when a decoder is free
+func (u *Uploads) thumbnail(data []byte) ([]byte, string, image.Point, error) {
+	u.decoding <- struct{}{}
+	defer func() { <-u.decoding }()
+	return thumbnail(data)
+}
+
+// GET /api/uploads/{key} serves an uploaded file. Only images are shown
+// inline, and never as a document that could run scripts.
+func (a *API) getUpload(w http.ResponseWriter, r *http.Request, vars map[string]string) {
+	u := a.hub.uploads
+	if u == nil {
+		http.Error(w, "uploads are disabled", 501)
+		return
+	}
+	blob, err := u.store.Get(vars["key"])
+	if err != nil {
+		http.Error(w, "Not found", 404)
+		return
+	}
+	defer blob.Close()
+	disposition := "attachment"
+	if strings.HasPrefix(blob.ContentType, "image/") {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", blob.ContentType)
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	if blob.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	}
+	io.Copy(w, blob)
+}
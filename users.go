@@ -0,0 +1,312 @@
+package webchat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// most unread messages counted per room
+const maxUnread = 999
+
+// Users remembers users with a verified identity across connections: their
+// last name, rooms and highlight keywords, a queue of messages that mentioned
+// them while they were offline and how far they have read each room.
+type Users struct {
+	mx        sync.Mutex
+	history   History
+	queueSize int
+	users     map[string]*user
+}
+
+type user struct {
+	name  string
+	rooms map[string]bool
+
+	// lower case words that highlight messages, see ParseKeywords
+	keywords []string
+
+	// messages waiting for the next connection, oldest first
+	queue []*Message
+
+	// last read MessageId by room
+	read map[string]int64
+
+	// at-least-once delivery is on and the messages not acknowledged yet
+	acking  bool
+	pending map[int64]*Message
+}
+
+// NewUsers keeps at most queueSize offline messages per user. Unread counts
+// are taken from history.
+func NewUsers(history History, queueSize int) *Users {
+	return &Users{
+		history:   history,
+		queueSize: queueSize,
+		users:     make(map[string]*user),
+	}
+}
+
+// get returns the user for identity, the lock must be held
+func (u *Users) get(identity string) *user {
+	us, ok := u.users[identity]
+	if ok == false {
+		us = &user{
+			rooms:   make(map[string]bool),
+			read:    make(map[string]int64),
+			pending: make(map[int64]*Message),
+		}
+		u.users[identity] = us
+	}
+	return us
+}
+
+// Seen records the name used by identity
+func (u *Users) Seen(identity, name string) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.get(identity).name = name
+}
+
+// Joined records that identity joined or left room. The first join starts
+// the read marker at lastId so old history does not count as unread.
+func (u *Users) Joined(identity, room string, joined bool, lastId int64) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	us := u.get(identity)
+	if joined == false {
+		delete(us.rooms, room)
+		return
+	}
+	us.rooms[room] = true
+	if _, ok := us.read[room]; ok == false {
+		us.read[room] = lastId
+	}
+}
+
+// SetKeywords remembers the keywords that highlight messages for identity
+func (u *Users) SetKeywords(identity string, keywords []string) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.get(identity).keywords = keywords
+}
+
+// Keywords returns the keywords identity set last
+func (u *Users) Keywords(identity string) []string {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	return u.get(identity).keywords
+}
+
+// Rooms returns the rooms identity is in
+func (u *Users) Rooms(identity string) []string {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	ret := make([]string, 0)
+	us, ok := u.users[identity]
+	if ok == false {
+		return ret
+	}
+	for room := range us.rooms {
+		ret = append(ret, room)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// offer queues m for offline users it mentions. @here only concerns users
+// that are online, @channel everyone who was in the room.
+func (u *Users) offer(m *Message, ms *mentions, online map[string]bool) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	for identity, us := range u.users {
+		if online[identity] || m.Author == IdentityAuthor(identity) {
+			continue
+		}
+		mentioned := (us.name != "" && ms.nicks[strings.ToLower(us.name)]) || ms.highlights(us.keywords)
+		if (ms.channel && us.rooms[m.Room]) || mentioned {
+			queued := *m
+			queued.connection = nil
+			queued.Notify = true
+			us.queue = append(us.queue, &queued)
+			if len(us.queue) > u.queueSize {
+				us.queue = us.queue[len(us.queue)-u.queueSize:]
+			}
+		}
+	}
+}
+
+// Drain returns and forgets the offline queue of identity
+func (u *Users) Drain(identity string) []*Message {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	us, ok := u.users[identity]
+	if ok == false {
+		return nil
+	}
+	ret := us.queue
+	us.queue = nil
+	return ret
+}
+
+// EnableAcks switches identity to at-least-once delivery
+func (u *Users) EnableAcks(identity string) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.get(identity).acking = true
+}
+
+// Acking reports whether identity uses at-least-once delivery
+func (u *Users) Acking(identity string) bool {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	us, ok := u.users[identity]
+	return ok && us.acking
+}
+
+// pend keeps m for the users of its room with at-least-once delivery until
+// they acknowledge it, whether they are online or not. The oldest are
+// dropped when more than queueSize are waiting.
+func (u *Users) pend(m *Message) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	for identity, us := range u.users {
+		if us.acking == false || us.rooms[m.Room] == false || m.Author == IdentityAuthor(identity) {
+			continue
+		}
+		u.keep(us, m)
+	}
+}
+
+// pendTo keeps m until identity acknowledges it
+func (u *Users) pendTo(identity string, m *Message) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	u.keep(u.get(identity), m)
+}
+
+// keep adds m to the pending messages of us, the lock must be held
+func (u *Users) keep(us *user, m *Message) {
+	kept := *m
+	kept.connection = nil
+	us.pending[m.MessageId] = &kept
+	for len(us.pending) > u.queueSize {
+		oldest := m.MessageId
+		for id := range us.pending {
+			if id < oldest {
+				oldest = id
+			}
+		}
+		delete(us.pending, oldest)
+	}
+}
+
+// Ack forgets a pending message
+func (u *Users) Ack(identity string, id int64) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	if us, ok := u.users[identity]; ok {
+		delete(us.pending, id)
+	}
+}
+
+// Pending returns the messages identity has not acknowledged, oldest first
+func (u *Users) Pending(identity string) []*Message {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	ret := make([]*Message, 0)
+	if us, ok := u.users[identity]; ok {
+		for _, m := range us.pending {
+			ret = append(ret, m)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].MessageId < ret[j].MessageId })
+	return ret
+}
+
+// MarkRead moves the read marker of identity in room forward to id
+func (u *Users) MarkRead(identity, room string, id int64) {
+	u.mx.Lock()
+	defer u.mx.Unlock()
+	us := u.get(identity)
+	if id > us.read[room] {
+		us.read[room] = id
+	}
+}
+
+// Unread counts messages by others after the read marker in each room the
+// identity joined
+func (u *Users) Unread(identity string) (map[string]int, error) {
+	u.mx.Lock()
+	read := make(map[string]int64)
+	if us, ok := u.users[identity]; ok {
+		for room := range us.rooms {
+			read[room] = us.read[room]
+		}
+	}
+	u.mx.Unlock()
+
+	author := IdentityAuthor(identity)
+	ret := make(map[string]int)
+	for room, marker := range read {
+		messages, err := u.history.Messages(room, 0, maxUnread)
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for _, m := range messages {
+			if m.MessageId > marker && m.Author != author {
+				n++
+			}
+		}
+		ret[room] = n
+	}
+	return ret, nil
+}
+
+// SetUsers enables offline queues and unread counts. It must be called before
+// Start.
+func (h *Hub) SetUsers(u *Users) {
+	h.users = u
+}
+
+// sendUnread answers an UnreadOp from c
+func (h *Hub) sendUnread(c *Connection) {
+	if h.users == nil || c.Identity == "" {
+		h.SendMessage(c, &Message{Op: NoticeOp, Message: "unread counts need a verified identity"})
+		return
+	}
+	unread, err := h.users.Unread(c.Identity)
+	if err != nil {
+		h.SendMessage(c, &Message{Op: NoticeOp, Message: fmt.Sprintf("unread: %s", err)})
+		return
+	}
+	h.SendMessage(c, &Message{Op: UnreadOp, Unread: unread})
+}
+
+// deliverOffline sends c the messages queued while its identity was offline.
+// Identities using at-least-once delivery also get every message they did
+// not acknowledge.
+func (h *Hub) deliverOffline(c *Connection) {
+	if h.users == nil || c.Identity == "" {
+		return
+	}
+	if h.users.Acking(c.Identity) {
+		h.mx.Lock()
+		c.acking = true
+		h.mx.Unlock()
+		for _, m := range h.users.Drain(c.Identity) {
+			// the copy that notifies replaces the pending one
+			h.users.pendTo(c.Identity, m)
+		}
+		for _, m := range h.users.Pending(c.Identity) {
+			h.SendMessage(c, m)
+		}
+	} else {
+		for _, m := range h.users.Drain(c.Identity) {
+			h.SendMessage(c, m)
+		}
+	}
+	h.sendUnread(c)
+}